@@ -0,0 +1,149 @@
+package conditions
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Getter is implemented by a resource that exposes its conditions itself.
+//
+// Nothing requires it: [Edit] and [ConditionsOf] find a Status.Conditions field
+// without it. It is for a kind whose conditions live somewhere else, or that
+// wants to keep reflection out of a hot path.
+type Getter interface {
+	GetConditions() []metav1.Condition
+}
+
+// Setter is a [Getter] whose conditions can be replaced as well.
+type Setter interface {
+	Getter
+	SetConditions([]metav1.Condition)
+}
+
+var conditionsType = reflect.TypeOf([]metav1.Condition(nil))
+
+// ConditionsOf returns the conditions of obj: what GetConditions returns if obj
+// is a [Getter], its Status.Conditions otherwise. A nil status pointer reads as
+// no conditions.
+//
+// It is what lets the read-only helpers take an object rather than a slice:
+//
+//	conds, err := conditions.ConditionsOf(obj)
+//	phase := stages.Phase(conds)
+func ConditionsOf(obj any) ([]metav1.Condition, error) {
+	if g, ok := obj.(Getter); ok {
+		return g.GetConditions(), nil
+	}
+
+	status, err := statusField(obj)
+	if err != nil {
+		return nil, err
+	}
+	if status.Kind() == reflect.Pointer {
+		if status.IsNil() {
+			return nil, nil
+		}
+		status = status.Elem()
+	}
+	return status.FieldByName("Conditions").Interface().([]metav1.Condition), nil
+}
+
+// Edit runs edit on the conditions of obj and stores what it leaves behind back
+// on obj, through SetConditions if obj is a [Setter] and into its
+// Status.Conditions otherwise.
+//
+// The status types across the modules share no interface, so instead of
+// requiring one, Edit finds the field by name: a Status struct or pointer to
+// one, holding a Conditions of type []metav1.Condition. A nil status pointer
+// is allocated only when edit leaves conditions to store, so that an edit that
+// changes nothing leaves the object as it was and [UpdateStatus] can still skip
+// the write.
+func Edit(obj any, edit func(conds *[]metav1.Condition)) error {
+	if s, ok := obj.(Setter); ok {
+		conds := s.GetConditions()
+		edit(&conds)
+		s.SetConditions(conds)
+		return nil
+	}
+
+	status, err := statusField(obj)
+	if err != nil {
+		return err
+	}
+
+	conds, _ := ConditionsOf(obj)
+	edit(&conds)
+
+	if status.Kind() == reflect.Pointer {
+		if status.IsNil() {
+			if len(conds) == 0 {
+				return nil
+			}
+			status.Set(reflect.New(status.Type().Elem()))
+		}
+		status = status.Elem()
+	}
+	status.FieldByName("Conditions").Set(reflect.ValueOf(conds))
+	return nil
+}
+
+// statusField returns the settable Status field of obj, having checked that it
+// holds a Conditions of the right type.
+func statusField(obj any) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%T is not a pointer to a struct", obj)
+	}
+
+	status := v.Elem().FieldByName("Status")
+	if !status.IsValid() {
+		return reflect.Value{}, fmt.Errorf("%T has no Status field", obj)
+	}
+
+	t := status.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("the Status of %T is not a struct", obj)
+	}
+	if f, ok := t.FieldByName("Conditions"); !ok || f.Type != conditionsType {
+		return reflect.Value{}, fmt.Errorf(
+			"the Status of %T has no Conditions of type []metav1.Condition", obj)
+	}
+	return status, nil
+}
+
+// UpdateConditions is [UpdateStatus] for a mutation of the conditions alone,
+// found on obj the way [Edit] finds them. edit gets the object's generation
+// alongside, which is what every builder here wants next to the slice:
+//
+//	err := conditions.UpdateConditions(ctx, cl, obj,
+//		func(conds *[]metav1.Condition, generation int64) {
+//			stages.Pass(conds, generation, "VolumeGroupCreated", "created")
+//		})
+//
+// A type Edit cannot find conditions on is reported before anything is read.
+func UpdateConditions[T client.Object](
+	ctx context.Context,
+	cl client.Client,
+	obj T,
+	edit func(conds *[]metav1.Condition, generation int64),
+) error {
+	if _, ok := any(obj).(Setter); !ok {
+		if _, err := statusField(obj); err != nil {
+			return err
+		}
+	}
+
+	return UpdateStatus(ctx, cl, obj, func(fresh T) {
+		// The type was checked above, and fresh is of the same one.
+		_ = Edit(fresh, func(conds *[]metav1.Condition) {
+			edit(conds, fresh.GetGeneration())
+		})
+	})
+}
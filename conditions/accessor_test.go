@@ -0,0 +1,188 @@
+package conditions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+// gadget is the other half of the status types out there: a pointer status,
+// nil on an object nobody has reconciled yet.
+type gadget struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+	Status *gadgetStatus
+}
+
+type gadgetStatus struct {
+	Phase      string
+	Conditions []metav1.Condition
+}
+
+func (g *gadget) DeepCopyObject() runtime.Object {
+	out := &gadget{TypeMeta: g.TypeMeta}
+	g.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if g.Status != nil {
+		out.Status = &gadgetStatus{Phase: g.Status.Phase}
+		for _, c := range g.Status.Conditions {
+			out.Status.Conditions = append(out.Status.Conditions, *c.DeepCopy())
+		}
+	}
+	return out
+}
+
+// tagged keeps its conditions under a field of another name, and says where
+// through Getter and Setter.
+type tagged struct {
+	conds []metav1.Condition
+}
+
+func (t *tagged) GetConditions() []metav1.Condition      { return t.conds }
+func (t *tagged) SetConditions(conds []metav1.Condition) { t.conds = conds }
+
+func TestEditAllocatesANilStatusPointer(t *testing.T) {
+	g := &gadget{}
+	err := conditions.Edit(g, func(conds *[]metav1.Condition) {
+		conditions.Set(conds, conditions.Ready(1, nil))
+	})
+	if err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if g.Status == nil || !conditions.IsTrue(g.Status.Conditions, conditions.TypeReady) {
+		t.Fatalf("Status = %+v, want Ready=True", g.Status)
+	}
+
+	conds, err := conditions.ConditionsOf(g)
+	if err != nil || len(conds) != 1 {
+		t.Errorf("ConditionsOf = %v, %v", conds, err)
+	}
+}
+
+// An edit that stores nothing must leave the nil pointer alone, or UpdateStatus
+// would see a change and write an empty status on every resync.
+func TestEditThatStoresNothingLeavesANilStatus(t *testing.T) {
+	g := &gadget{}
+	if err := conditions.Edit(g, func(conds *[]metav1.Condition) {
+		conditions.Remove(conds, conditions.TypeReady)
+	}); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if g.Status != nil {
+		t.Errorf("Status = %+v, want it left nil", g.Status)
+	}
+}
+
+func TestEditAValueStatus(t *testing.T) {
+	w := &widget{}
+	s := conditions.Stages{Types: []string{"A", "B"}}
+	if err := conditions.Edit(w, func(conds *[]metav1.Condition) {
+		s.Pass(conds, 1, "A", "done")
+	}); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if got := types(w.Status.Conditions); len(got) != 1 || got[0] != "A" {
+		t.Errorf("conditions = %v, want [A]", got)
+	}
+}
+
+func TestEditGoesThroughSetter(t *testing.T) {
+	obj := &tagged{}
+	if err := conditions.Edit(obj, func(conds *[]metav1.Condition) {
+		conditions.Set(conds, conditions.Ready(1, nil))
+	}); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if !conditions.IsTrue(obj.conds, conditions.TypeReady) {
+		t.Errorf("conditions = %+v, want Ready=True", obj.conds)
+	}
+}
+
+func TestEditRejectsATypeWithoutConditions(t *testing.T) {
+	for name, obj := range map[string]any{
+		"no Status field":        &struct{ Spec string }{},
+		"conditions of a kind":   &corev1.Pod{},
+		"not a pointer":          gadget{},
+		"a nil pointer":          (*gadget)(nil),
+		"a status of other type": &struct{ Status string }{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			called := false
+			err := conditions.Edit(obj, func(*[]metav1.Condition) { called = true })
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if called {
+				t.Error("edit must not run on a type without conditions")
+			}
+			if _, err := conditions.ConditionsOf(obj); err == nil {
+				t.Error("ConditionsOf: expected an error")
+			}
+		})
+	}
+}
+
+var widgetGVK = schema.GroupVersionKind{Group: "test.deckhouse.io", Version: "v1", Kind: "Widget"}
+
+func newWidgetClient(t *testing.T, objs ...client.Object) client.WithWatch {
+	t.Helper()
+	s := runtime.NewScheme()
+	s.AddKnownTypeWithName(widgetGVK, &widget{})
+	return fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(objs...).
+		WithStatusSubresource(&widget{}).
+		Build()
+}
+
+func TestUpdateConditions(t *testing.T) {
+	stored := &widget{ObjectMeta: metav1.ObjectMeta{Name: "w", Namespace: "default", Generation: 3}}
+	cl := newWidgetClient(t, stored)
+	s := conditions.Stages{Types: []string{"A"}}
+
+	err := conditions.UpdateConditions(context.Background(), cl, &widget{ObjectMeta: stored.ObjectMeta},
+		func(conds *[]metav1.Condition, generation int64) {
+			s.Fail(conds, generation, "A", errors.New("boom"))
+		})
+	if err != nil {
+		t.Fatalf("UpdateConditions: %v", err)
+	}
+
+	got := &widget{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(stored), got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	c := find(t, got.Status.Conditions, "A")
+	if c.Status != metav1.ConditionFalse || c.ObservedGeneration != 3 {
+		t.Errorf("A = %+v, want False at generation 3", c)
+	}
+}
+
+func TestUpdateConditionsRejectsATypeWithoutConditionsBeforeReading(t *testing.T) {
+	reads := 0
+	cl := fake.NewClientBuilder().
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				reads++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	err := conditions.UpdateConditions(context.Background(), cl, newPod(), func(*[]metav1.Condition, int64) {})
+	if err == nil {
+		t.Fatal("a Pod has no []metav1.Condition, expected an error")
+	}
+	if reads != 0 {
+		t.Errorf("expected no read, got %d", reads)
+	}
+}
@@ -0,0 +1,130 @@
+package conditions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ApplyStatus publishes conds on obj as a server-side apply patch of its status
+// subresource, owned by fieldManager.
+//
+// It is the companion to [UpdateStatus] for resources that more than one
+// controller publishes conditions on. UpdateStatus writes the whole status from
+// a freshly-read copy, so two controllers doing that on one resource keep
+// conflicting with each other, and the loser of a race retries against a status
+// it then writes back in full. Here only the conditions in conds travel, and the
+// apiserver merges them by type, so each controller owns its own condition types
+// and leaves the rest alone without a retry.
+//
+// That merge is the CRD's to declare, not this function's: metav1.Condition
+// carries no list type of its own, and the conditions field has to be marked
+//
+//	// +listType=map
+//	// +listMapKey=type
+//	Conditions []metav1.Condition `json:"conditions,omitempty"`
+//
+// Without the markers the list is atomic, every apply replaces it whole, and
+// with ownership forced two controllers wipe out each other's conditions on
+// every pass — the very thing this function is for avoiding. Check the
+// generated CRD for x-kubernetes-list-type: map before switching a kind over.
+//
+// The patch is the complete set fieldManager owns: a condition type it applied
+// before and leaves out of conds now is removed by the apiserver. That is the
+// point of apply semantics, and the reason conds cannot be a partial update.
+//
+// The current conditions are read once, so that LastTransitionTime survives a
+// pass that leaves a condition's status where it was, with the semantics [Set]
+// gives it. Ownership is forced: a condition type is meant to have a single
+// publisher, and a field another manager once wrote through a plain update must
+// not keep the rightful one from taking it over.
+//
+// A patch that changes nothing is not skipped here the way UpdateStatus skips a
+// no-op write: the apiserver already drops it without persisting anything, and
+// skipping it on this side would leave a condition type dropped from conds on the
+// resource.
+//
+// obj itself is left untouched — it is only used for its key and its type.
+func ApplyStatus(
+	ctx context.Context,
+	cl client.Client,
+	obj client.Object,
+	fieldManager string,
+	conds ...metav1.Condition,
+) error {
+	if fieldManager == "" {
+		return errors.New("applying conditions needs a field manager")
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, cl.Scheme())
+	if err != nil {
+		return fmt.Errorf("resolving the kind of %T: %w", obj, err)
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(gvk)
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+		return err
+	}
+
+	existing, err := unstructuredConditions(current.Object)
+	if err != nil {
+		return err
+	}
+
+	owned := make([]any, 0, len(conds))
+	seen := make(map[string]struct{}, len(conds))
+	for _, c := range conds {
+		if _, dup := seen[c.Type]; dup {
+			return fmt.Errorf("condition %q is applied twice", c.Type)
+		}
+		seen[c.Type] = struct{}{}
+
+		Set(&existing, c)
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(Get(existing, c.Type))
+		if err != nil {
+			return fmt.Errorf("converting condition %q: %w", c.Type, err)
+		}
+		owned = append(owned, u)
+	}
+
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(gvk)
+	patch.SetName(obj.GetName())
+	patch.SetNamespace(obj.GetNamespace())
+	if err := unstructured.SetNestedSlice(patch.Object, owned, "status", "conditions"); err != nil {
+		return err
+	}
+
+	return cl.Status().Patch(ctx, patch, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// unstructuredConditions reads status.conditions out of an object in its
+// unstructured form, which is what lets the helpers here work on a kind whose
+// status type they know nothing about.
+func unstructuredConditions(obj map[string]any) ([]metav1.Condition, error) {
+	raw, _, err := unstructured.NestedSlice(obj, "status", "conditions")
+	if err != nil {
+		return nil, fmt.Errorf("reading status.conditions: %w", err)
+	}
+
+	conds := make([]metav1.Condition, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("status.conditions holds a %T, not an object", item)
+		}
+		var c metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &c); err != nil {
+			return nil, fmt.Errorf("reading status.conditions: %w", err)
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
@@ -0,0 +1,266 @@
+package conditions_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+// appliedPatch is what ApplyStatus handed to the status subresource. The fake
+// client does not implement server-side apply, so the tests look at the request
+// rather than at its effect.
+type appliedPatch struct {
+	patchType  k8stypes.PatchType
+	fieldOwner string
+	force      bool
+	conditions []metav1.Condition
+}
+
+func newApplyClient(t *testing.T, got *[]appliedPatch, objs ...client.Object) client.Client {
+	t.Helper()
+	return fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&corev1.Pod{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(
+				_ context.Context,
+				_ client.Client,
+				_ string,
+				obj client.Object,
+				patch client.Patch,
+				opts ...client.SubResourcePatchOption,
+			) error {
+				o := &client.SubResourcePatchOptions{}
+				o.ApplyOptions(opts)
+
+				data, err := patch.Data(obj)
+				if err != nil {
+					t.Fatalf("rendering the patch: %v", err)
+				}
+				var body struct {
+					Status struct {
+						Conditions []metav1.Condition `json:"conditions"`
+					} `json:"status"`
+				}
+				if err := json.Unmarshal(data, &body); err != nil {
+					t.Fatalf("decoding the patch %s: %v", data, err)
+				}
+
+				*got = append(*got, appliedPatch{
+					patchType:  patch.Type(),
+					fieldOwner: o.FieldManager,
+					force:      o.Force != nil && *o.Force,
+					conditions: body.Status.Conditions,
+				})
+				return nil
+			},
+		}).
+		Build()
+}
+
+func TestApplyStatus_SendsOnlyTheOwnedConditionsAsAnApplyPatch(t *testing.T) {
+	pod := newPod()
+	pod.Status.Conditions = []corev1.PodCondition{
+		{Type: "OwnedBySomeoneElse", Status: corev1.ConditionTrue, Reason: "Theirs"},
+	}
+
+	var got []appliedPatch
+	cl := newApplyClient(t, &got, pod)
+
+	err := conditions.ApplyStatus(context.Background(), cl, pod, "node-controller",
+		metav1.Condition{Type: "NodePrepared", Status: metav1.ConditionTrue, Reason: "Reconciled", ObservedGeneration: 2},
+	)
+	if err != nil {
+		t.Fatalf("ApplyStatus: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected one patch, got %d", len(got))
+	}
+	p := got[0]
+	if p.patchType != k8stypes.ApplyPatchType {
+		t.Errorf("patch type = %q, want %q", p.patchType, k8stypes.ApplyPatchType)
+	}
+	if p.fieldOwner != "node-controller" {
+		t.Errorf("field manager = %q, want node-controller", p.fieldOwner)
+	}
+	if !p.force {
+		t.Error("a condition type has one publisher, so ownership must be forced")
+	}
+
+	// The condition another manager publishes must not travel: sending it would
+	// claim it, and the point is that each controller owns only its own.
+	if len(p.conditions) != 1 || p.conditions[0].Type != "NodePrepared" {
+		t.Fatalf("expected only NodePrepared in the patch, got %v", types(p.conditions))
+	}
+	if p.conditions[0].LastTransitionTime.IsZero() {
+		t.Error("a new condition must carry a transition time, the schema requires it")
+	}
+	if p.conditions[0].ObservedGeneration != 2 {
+		t.Errorf("observedGeneration = %d, want 2", p.conditions[0].ObservedGeneration)
+	}
+}
+
+// An apply carries whole conditions, so a pass that only restates a condition
+// would otherwise make it look like it just transitioned.
+func TestApplyStatus_KeepsTheTransitionTimeOfAnUnchangedStatus(t *testing.T) {
+	since := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	pod := newPod()
+	pod.Status.Conditions = []corev1.PodCondition{
+		{Type: "NodePrepared", Status: corev1.ConditionTrue, Reason: "Reconciled", LastTransitionTime: since},
+	}
+
+	var got []appliedPatch
+	cl := newApplyClient(t, &got, pod)
+
+	err := conditions.ApplyStatus(context.Background(), cl, pod, "node-controller",
+		metav1.Condition{Type: "NodePrepared", Status: metav1.ConditionTrue, Reason: "Reconciled", Message: "resynced"},
+	)
+	if err != nil {
+		t.Fatalf("ApplyStatus: %v", err)
+	}
+
+	c := got[0].conditions[0]
+	if !c.LastTransitionTime.Equal(&since) {
+		t.Errorf("lastTransitionTime = %v, want %v", c.LastTransitionTime, since)
+	}
+	if c.Message != "resynced" {
+		t.Errorf("message = %q, want the new one", c.Message)
+	}
+}
+
+func TestApplyStatus_RejectsABadRequestBeforeWriting(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		manager string
+		conds   []metav1.Condition
+	}{
+		{"no field manager", "", []metav1.Condition{{Type: "A", Status: metav1.ConditionTrue, Reason: "R"}}},
+		{
+			"a type applied twice",
+			"node-controller",
+			[]metav1.Condition{
+				{Type: "A", Status: metav1.ConditionTrue, Reason: "R"},
+				{Type: "A", Status: metav1.ConditionFalse, Reason: "R"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := newPod()
+			var got []appliedPatch
+			cl := newApplyClient(t, &got, pod)
+
+			if err := conditions.ApplyStatus(context.Background(), cl, pod, tc.manager, tc.conds...); err == nil {
+				t.Fatal("expected an error")
+			}
+			if len(got) != 0 {
+				t.Errorf("nothing must be written, got %d patches", len(got))
+			}
+		})
+	}
+}
+
+// conditionsSchema is status.conditions the way a CRD declares it, with the
+// list type ApplyStatus depends on in place of listType.
+func conditionsSchema(listType string) typed.YAMLObject {
+	return typed.YAMLObject(`types:
+- name: status
+  map:
+    fields:
+    - name: conditions
+      type:
+        list:
+          elementType:
+            namedType: condition
+          elementRelationship: ` + listType + `
+          keys: [type]
+- name: condition
+  map:
+    fields:
+    - name: type
+      type: {scalar: string}
+    - name: status
+      type: {scalar: string}
+`)
+}
+
+type sameVersion struct{}
+
+func (sameVersion) Convert(v *typed.TypedValue, _ fieldpath.APIVersion) (*typed.TypedValue, error) {
+	return v, nil
+}
+func (sameVersion) IsMissingVersionError(error) bool { return false }
+
+// ApplyStatus leaves another manager's conditions alone only if the CRD
+// declares the list +listType=map with +listMapKey=type: metav1.Condition
+// carries no marker of its own, and an atomic list is replaced whole by every
+// forced apply. The fake client does not merge apply patches, so this runs the
+// apiserver's merge on both schemas.
+func TestApplyStatus_NeedsAMapList(t *testing.T) {
+	for _, tc := range []struct {
+		listType string
+		want     []string
+	}{
+		{"associative", []string{"Mounted", "Published"}},
+		{"atomic", []string{"Published"}},
+	} {
+		t.Run(tc.listType, func(t *testing.T) {
+			parser, err := typed.NewParser(conditionsSchema(tc.listType))
+			if err != nil {
+				t.Fatalf("parsing the schema: %v", err)
+			}
+			status := parser.Type("status")
+			updater := &merge.Updater{Converter: sameVersion{}}
+
+			live, err := status.FromYAML(`{}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			managers := fieldpath.ManagedFields{}
+			for _, applied := range []struct{ manager, yaml string }{
+				{"node-agent", `{"conditions": [{"type": "Mounted", "status": "True"}]}`},
+				{"controller", `{"conditions": [{"type": "Published", "status": "True"}]}`},
+			} {
+				config, err := status.FromYAML(typed.YAMLObject(applied.yaml))
+				if err != nil {
+					t.Fatal(err)
+				}
+				live, managers, err = updater.Apply(live, config, "v1", managers, applied.manager, true)
+				if err != nil {
+					t.Fatalf("applying as %s: %v", applied.manager, err)
+				}
+			}
+
+			var got struct {
+				Conditions []metav1.Condition `json:"conditions"`
+			}
+			data, err := json.Marshal(live.AsValue().Unstructured())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if applied := types(got.Conditions); !slices.Equal(applied, tc.want) {
+				t.Errorf("conditions = %v, want %v", applied, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package conditions
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/deckhouse/sds-common-lib/cooldown"
+)
+
+// DefaultBatchConcurrency is how many objects [UpdateStatuses] updates at once
+// when [BatchOptions.Concurrency] is not set.
+const DefaultBatchConcurrency = 4
+
+// StatusUpdate is one object of a batch handed to [UpdateStatuses], together
+// with the mutation of its status.
+type StatusUpdate[T client.Object] struct {
+	Object T
+	Mutate func(T)
+}
+
+// BatchOptions tunes [UpdateStatuses].
+type BatchOptions struct {
+	// Concurrency is how many objects are updated at once.
+	// Defaults to [DefaultBatchConcurrency].
+	Concurrency int
+
+	// Cooldown, when set, is hit before every write, so that it paces the
+	// writes of the whole batch — and of every other batch sharing it — rather
+	// than those of one worker. A write the no-op check skips does not hit it.
+	//
+	// It wants a rate limiter: [cooldown.TokenBucketCooldown] or
+	// [cooldown.SlidingWindowCooldown]. A [cooldown.ExponentialCooldown] grows
+	// its delay on every hit made inside the cooldown, which every write of a
+	// batch is, so it ends up writing one object per maxDelay.
+	Cooldown cooldown.Cooldown
+}
+
+// UpdateStatuses is [UpdateStatus] for many objects at once, and returns the
+// error of each object that failed, keyed by its object key. It returns nil
+// when every update succeeded.
+//
+// It exists for the reconcilers that fan out over dozens of child objects. One
+// UpdateStatus after another makes a reconcile as slow as the sum of the
+// round trips, and firing them all at once hammers the apiserver exactly when a
+// large change lands on many objects. Here at most opts.Concurrency updates run
+// at a time, and the writes are paced by opts.Cooldown.
+//
+// Every update has the semantics of UpdateStatus: mutate runs on freshly-read
+// state, is retried on conflict, and a mutation that changes nothing is not
+// written. One object failing does not stop the others. An update that had not
+// started when ctx was cancelled fails with ctx.Err().
+//
+// Each object is expected once: a second update of the same key would race with
+// the first, and only one of their errors could be reported.
+func UpdateStatuses[T client.Object](
+	ctx context.Context,
+	cl client.Client,
+	updates []StatusUpdate[T],
+	opts BatchOptions,
+) map[client.ObjectKey]error {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	write := writeStatus[T](cl)
+	if opts.Cooldown != nil {
+		unpaced := write
+		write = func(ctx context.Context, before, fresh T) error {
+			if err := opts.Cooldown.Hit(ctx); err != nil {
+				return err
+			}
+			return unpaced(ctx, before, fresh)
+		}
+	}
+
+	var (
+		mu   sync.Mutex
+		errs map[client.ObjectKey]error
+		wg   sync.WaitGroup
+	)
+	fail := func(key client.ObjectKey, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if errs == nil {
+			errs = make(map[client.ObjectKey]error)
+		}
+		errs[key] = err
+	}
+
+	slots := make(chan struct{}, concurrency)
+	for _, u := range updates {
+		key := client.ObjectKeyFromObject(u.Object)
+		if err := ctx.Err(); err != nil {
+			fail(key, err)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			fail(key, ctx.Err())
+			continue
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := updateStatus(ctx, readFresh(cl, u.Object), write, u.Mutate); err != nil {
+				fail(key, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
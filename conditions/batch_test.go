@@ -0,0 +1,191 @@
+package conditions_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+	"github.com/deckhouse/sds-common-lib/cooldown"
+)
+
+type countingCooldown struct{ hits atomic.Int32 }
+
+func (c *countingCooldown) Hit(ctx context.Context) error {
+	c.hits.Add(1)
+	return ctx.Err()
+}
+
+func pods(n int) []client.Object {
+	out := make([]client.Object, 0, n)
+	for i := range n {
+		out = append(out, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}})
+	}
+	return out
+}
+
+func updatesOf(objs []client.Object, mutate func(*corev1.Pod)) []conditions.StatusUpdate[*corev1.Pod] {
+	out := make([]conditions.StatusUpdate[*corev1.Pod], 0, len(objs))
+	for _, o := range objs {
+		out = append(out, conditions.StatusUpdate[*corev1.Pod]{Object: o.(*corev1.Pod), Mutate: mutate})
+	}
+	return out
+}
+
+func TestUpdateStatuses_WritesEveryObjectAndReportsEachFailure(t *testing.T) {
+	objs := pods(5)
+	cl := newClient(t, objs...)
+	missing := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gone", Namespace: "default"}}
+
+	updates := append(updatesOf(objs, func(p *corev1.Pod) { p.Status.Message = "written" }),
+		conditions.StatusUpdate[*corev1.Pod]{Object: missing, Mutate: func(p *corev1.Pod) { p.Status.Message = "written" }})
+
+	errs := conditions.UpdateStatuses(context.Background(), cl, updates, conditions.BatchOptions{})
+
+	if len(errs) != 1 || !apierrors.IsNotFound(errs[client.ObjectKeyFromObject(missing)]) {
+		t.Fatalf("expected a NotFound for the missing pod only, got %v", errs)
+	}
+	for _, o := range objs {
+		got := &corev1.Pod{}
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(o), got); err != nil {
+			t.Fatalf("reading %s back: %v", o.GetName(), err)
+		}
+		if got.Status.Message != "written" {
+			t.Errorf("%s: expected the mutation to be persisted, got %q", o.GetName(), got.Status.Message)
+		}
+	}
+}
+
+func TestUpdateStatuses_BoundsConcurrency(t *testing.T) {
+	objs := pods(12)
+
+	var inFlight, peak atomic.Int32
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&corev1.Pod{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(
+				ctx context.Context,
+				c client.Client,
+				subResourceName string,
+				obj client.Object,
+				opts ...client.SubResourceUpdateOption,
+			) error {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+
+	errs := conditions.UpdateStatuses(context.Background(), cl,
+		updatesOf(objs, func(p *corev1.Pod) { p.Status.Message = "written" }),
+		conditions.BatchOptions{Concurrency: 3})
+	if errs != nil {
+		t.Fatalf("UpdateStatuses: %v", errs)
+	}
+
+	if got := peak.Load(); got > 3 {
+		t.Errorf("at most 3 writes may be in flight, saw %d", got)
+	}
+	if got := peak.Load(); got < 2 {
+		t.Errorf("the writes should have overlapped, saw at most %d at once", got)
+	}
+}
+
+// The cooldown paces writes, and a resync that changes nothing writes nothing —
+// it must not spend the budget of the writes that do.
+func TestUpdateStatuses_PacesOnlyTheWrites(t *testing.T) {
+	objs := pods(4)
+	objs[0].(*corev1.Pod).Status.Message = "written"
+	cl := newClient(t, objs...)
+	cd := &countingCooldown{}
+
+	errs := conditions.UpdateStatuses(context.Background(), cl,
+		updatesOf(objs, func(p *corev1.Pod) { p.Status.Message = "written" }),
+		conditions.BatchOptions{Cooldown: cd})
+	if errs != nil {
+		t.Fatalf("UpdateStatuses: %v", errs)
+	}
+
+	if got := cd.hits.Load(); got != 3 {
+		t.Errorf("expected the cooldown to be hit once per write, 3, got %d", got)
+	}
+}
+
+// A token bucket shared by the workers holds the whole batch to its rate.
+func TestUpdateStatuses_KeepsThePaceOfTheCooldown(t *testing.T) {
+	objs := pods(6)
+	cl := newClient(t, objs...)
+	clock := cooldown.NewFakeClock(time.Unix(0, 0))
+	cd := cooldown.NewTokenBucketCooldown(time.Second, 2, cooldown.WithClock(clock))
+
+	done := make(chan map[client.ObjectKey]error)
+	go func() {
+		done <- conditions.UpdateStatuses(context.Background(), cl,
+			updatesOf(objs, func(p *corev1.Pod) { p.Status.Message = "paced" }),
+			conditions.BatchOptions{Concurrency: 6, Cooldown: cd})
+	}()
+
+	for {
+		select {
+		case errs := <-done:
+			if errs != nil {
+				t.Fatalf("UpdateStatuses: %v", errs)
+			}
+			// Two writes go in the burst, the other four a second apart.
+			if got := clock.Now().Sub(time.Unix(0, 0)); got != 4*time.Second {
+				t.Errorf("the batch took %s on the cooldown's clock, want 4s", got)
+			}
+			return
+		default:
+		}
+		if at, ok := clock.NextDeadline(); ok {
+			clock.Advance(at.Sub(clock.Now()))
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestUpdateStatuses_ReportsWhatACancelledContextLeftUndone(t *testing.T) {
+	objs := pods(3)
+	cl := newClient(t, objs...)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var mutated atomic.Bool
+	errs := conditions.UpdateStatuses(ctx, cl,
+		updatesOf(objs, func(*corev1.Pod) { mutated.Store(true) }),
+		conditions.BatchOptions{})
+
+	if len(errs) != len(objs) {
+		t.Fatalf("every object should report the cancellation, got %v", errs)
+	}
+	for key, err := range errs {
+		if err != context.Canceled {
+			t.Errorf("%s: got %v, want %v", key, err, context.Canceled)
+		}
+	}
+	if mutated.Load() {
+		t.Error("an update that never started must not have run its mutation")
+	}
+}
@@ -1,11 +1,20 @@
 // Package conditions provides a single way for storage modules to publish
 // `status.conditions` on their custom resources.
 //
-// It deliberately works on a plain *[]metav1.Condition rather than on a
+// The helpers work on a plain *[]metav1.Condition rather than on a
 // Getter/Setter interface: the CRD status types across the modules are a mix of
-// value and pointer structs, and none of them implement a common interface
-// today. Taking the slice directly keeps the helper usable without touching
-// every API package first.
+// value and pointer structs, and few of them implement a common interface.
+// Taking the slice directly keeps the helpers usable without touching every API
+// package first.
+//
+// Callers that would rather hand in the object go through [Edit],
+// [ConditionsOf] and [UpdateConditions], which find Status.Conditions on any
+// such type — allocating a nil status pointer when there is something to store —
+// or use the opt-in [Getter] and [Setter] where a type implements them.
+//
+// A kind that more than one controller publishes conditions on goes through
+// [ApplyStatus], which needs the CRD to declare status.conditions with
+// +listType=map and +listMapKey=type; see there.
 //
 // The Kubernetes API conventions this package follows are documented in
 // https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md#typical-status-properties
@@ -29,6 +38,10 @@ import (
 // [Aggregate].
 const TypeReady = "Ready"
 
+// TypeHealthy is the aggregate of the advisory stages of a resource — the
+// checks that warn rather than hold it not ready. See [Stages.Advisory].
+const TypeHealthy = "Healthy"
+
 // Condition reasons shared across modules. Reasons are short, stable,
 // machine-readable CamelCase identifiers; human-readable detail belongs in
 // condition.message, never in the reason.
@@ -51,6 +64,9 @@ const (
 	// ReasonDeleting is set on Ready=False while the resource is being torn
 	// down after a deletion request.
 	ReasonDeleting = "Deleting"
+	// ReasonStalled is set on Ready=False when a stage has been in progress for
+	// longer than its deadline — see [Stages.Deadlines].
+	ReasonStalled = "Stalled"
 )
 
 // Set adds or updates cond in conds and reports whether anything changed.
@@ -104,6 +120,7 @@ func IsUnknown(conds []metav1.Condition, conditionType string) bool {
 // from "the controller has not looked at your latest change yet", which a bare
 // status value cannot express. Callers gating on a dependency should treat a
 // stale condition as not-ready regardless of its status.
+// [Stages.RequireCurrentGeneration] applies the same reading to a set of stages.
 func IsStale(conds []metav1.Condition, conditionType string, generation int64) bool {
 	c := meta.FindStatusCondition(conds, conditionType)
 	return c == nil || c.ObservedGeneration < generation
@@ -144,15 +161,20 @@ const MaxMessageLen = 32768
 // condition, a kind-specific type — needs the same bound, and re-deriving it
 // per module is how the constant drifts from the schema.
 func TruncateMessage(msg string) string {
+	return truncate(msg, MaxMessageLen)
+}
+
+// truncate clips msg to limit runes the way [TruncateMessage] describes.
+func truncate(msg string, limit int) string {
 	// Counted rather than converted: every condition this package builds goes
 	// through here, and almost none of them are anywhere near the cap. Taking
 	// []rune first would copy the whole string on every call just to measure it.
-	if utf8.RuneCountInString(msg) <= MaxMessageLen {
+	if utf8.RuneCountInString(msg) <= limit {
 		return msg
 	}
 
 	const ellipsis = "..."
-	return string([]rune(msg)[:MaxMessageLen-utf8.RuneCountInString(ellipsis)]) + ellipsis
+	return string([]rune(msg)[:limit-utf8.RuneCountInString(ellipsis)]) + ellipsis
 }
 
 // Ready builds the aggregate Ready condition for a reconcile pass that ended
@@ -162,9 +184,10 @@ func TruncateMessage(msg string) string {
 //	cond := conditions.Ready(obj.Generation, err)
 //
 // On failure the error text becomes the message, so err should be wrapped with
-// enough context to be readable in `kubectl describe` — and must not carry
+// enough context to be readable in `kubectl describe` — and should not carry
 // secrets, since conditions are world-readable to anyone who can get the
-// resource. The message is truncated to [MaxMessageLen].
+// resource. The message goes through [DefaultRedactor], which catches the common
+// shapes, and is truncated to [MaxMessageLen].
 //
 // A successful pass gets no message. Use [ReadyWithMessage] to have it report
 // what it achieved.
@@ -192,18 +215,17 @@ func Ready(generation int64, err error) metav1.Condition {
 // what it managed to do before that is not what an operator needs to read first.
 // Callers wanting both should wrap the error with the context instead.
 //
-// Neither msg nor err must carry secrets: conditions are readable by anyone who
-// can get the resource, and a backend error text routinely echoes the request it
-// failed on, credentials in the URL included.
-//
-// Both messages are truncated to [MaxMessageLen].
+// Neither msg nor err should carry secrets: conditions are readable by anyone
+// who can get the resource, and a backend error text routinely echoes the
+// request it failed on, credentials in the URL included. Both messages go
+// through [DefaultRedactor] and are truncated to [MaxMessageLen].
 func ReadyWithMessage(generation int64, msg string, err error) metav1.Condition {
 	if err != nil {
 		return metav1.Condition{
 			Type:               TypeReady,
 			Status:             metav1.ConditionFalse,
 			Reason:             ReasonReconcileFailed,
-			Message:            TruncateMessage(err.Error()),
+			Message:            sanitize(err.Error()),
 			ObservedGeneration: generation,
 		}
 	}
@@ -212,7 +234,7 @@ func ReadyWithMessage(generation int64, msg string, err error) metav1.Condition
 		Type:               TypeReady,
 		Status:             metav1.ConditionTrue,
 		Reason:             ReasonReconciled,
-		Message:            TruncateMessage(msg),
+		Message:            sanitize(msg),
 		ObservedGeneration: generation,
 	}
 }
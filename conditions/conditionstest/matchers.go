@@ -0,0 +1,261 @@
+// Package conditionstest provides assertions on conditions for the tests of
+// controllers built on package conditions.
+//
+// The matchers are Gomega matchers, for the Ginkgo suites:
+//
+//	Expect(obj).To(conditionstest.HaveCondition("VolumeGroupReady").
+//		WithStatus(metav1.ConditionFalse).
+//		WithReason(conditions.ReasonReconcileFailed))
+//	Expect(obj.Status.Conditions).To(conditionstest.BeReadyFor(obj.Generation))
+//
+// and [Assert] and [Require] take the same matchers in a testify-style test:
+//
+//	conditionstest.Require(t, obj, conditionstest.HavePhase(stages, conditions.PhaseReady))
+//
+// A matcher takes a []metav1.Condition, a pointer to one, or any object
+// [conditions.ConditionsOf] finds conditions on. A failure prints the whole
+// condition set, with the condition in question marked and what differs spelled
+// out, which is what the hand-written loops over conditions never did.
+package conditionstest
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/onsi/gomega/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+// ConditionMatcher matches a condition set that has a condition of a type,
+// optionally with a given status, reason, message and observed generation.
+// Build one with [HaveCondition] or [BeReadyFor].
+type ConditionMatcher struct {
+	condType        string
+	status          *metav1.ConditionStatus
+	reason          *string
+	message         *string
+	messageContains *string
+	generation      *int64
+}
+
+var _ types.GomegaMatcher = ConditionMatcher{}
+
+// HaveCondition matches a condition set that has a condition of condType.
+func HaveCondition(condType string) ConditionMatcher {
+	return ConditionMatcher{condType: condType}
+}
+
+// BeReadyFor matches a condition set whose [conditions.TypeReady] is True and
+// was recorded for generation — ready for the spec the test last wrote, not for
+// one before it.
+func BeReadyFor(generation int64) ConditionMatcher {
+	return HaveCondition(conditions.TypeReady).
+		WithStatus(metav1.ConditionTrue).
+		WithObservedGeneration(generation)
+}
+
+// WithStatus returns m that also requires the condition to have status.
+func (m ConditionMatcher) WithStatus(status metav1.ConditionStatus) ConditionMatcher {
+	m.status = &status
+	return m
+}
+
+// WithReason returns m that also requires the condition to have reason.
+func (m ConditionMatcher) WithReason(reason string) ConditionMatcher {
+	m.reason = &reason
+	return m
+}
+
+// WithMessage returns m that also requires the condition to have exactly
+// message.
+func (m ConditionMatcher) WithMessage(message string) ConditionMatcher {
+	m.message = &message
+	return m
+}
+
+// WithMessageContaining returns m that also requires the message of the
+// condition to contain substr.
+func (m ConditionMatcher) WithMessageContaining(substr string) ConditionMatcher {
+	m.messageContains = &substr
+	return m
+}
+
+// WithObservedGeneration returns m that also requires the condition to have
+// been recorded for generation.
+func (m ConditionMatcher) WithObservedGeneration(generation int64) ConditionMatcher {
+	m.generation = &generation
+	return m
+}
+
+// Match implements [types.GomegaMatcher].
+func (m ConditionMatcher) Match(actual any) (bool, error) {
+	conds, err := conditionsOf(actual)
+	if err != nil {
+		return false, err
+	}
+	c := conditions.Get(conds, m.condType)
+	return c != nil && len(m.mismatches(c)) == 0, nil
+}
+
+// FailureMessage implements [types.GomegaMatcher].
+func (m ConditionMatcher) FailureMessage(actual any) string {
+	conds, _ := conditionsOf(actual)
+
+	var b strings.Builder
+	if c := conditions.Get(conds, m.condType); c == nil {
+		fmt.Fprintf(&b, "expected a %s condition, there is none\n", m.condType)
+	} else {
+		fmt.Fprintf(&b, "expected %s to match %s:\n", m.condType, m)
+		for _, d := range m.mismatches(c) {
+			fmt.Fprintf(&b, "  %s\n", d)
+		}
+	}
+	b.WriteString(Describe(conds, m.condType))
+	return b.String()
+}
+
+// NegatedFailureMessage implements [types.GomegaMatcher].
+func (m ConditionMatcher) NegatedFailureMessage(actual any) string {
+	conds, _ := conditionsOf(actual)
+	return fmt.Sprintf("expected %s not to match %s\n%s", m.condType, m, Describe(conds, m.condType))
+}
+
+// String describes what m expects, as in "status True, reason Reconciled".
+func (m ConditionMatcher) String() string {
+	var parts []string
+	if m.status != nil {
+		parts = append(parts, "status "+string(*m.status))
+	}
+	if m.reason != nil {
+		parts = append(parts, "reason "+*m.reason)
+	}
+	if m.message != nil {
+		parts = append(parts, fmt.Sprintf("message %q", *m.message))
+	}
+	if m.messageContains != nil {
+		parts = append(parts, fmt.Sprintf("message containing %q", *m.messageContains))
+	}
+	if m.generation != nil {
+		parts = append(parts, fmt.Sprintf("observed generation %d", *m.generation))
+	}
+	if len(parts) == 0 {
+		return "any condition"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// mismatches lists, one line each, what about c is not what m expects.
+func (m ConditionMatcher) mismatches(c *metav1.Condition) []string {
+	var diffs []string
+	if m.status != nil && c.Status != *m.status {
+		diffs = append(diffs, fmt.Sprintf("status:  want %s, got %s", *m.status, c.Status))
+	}
+	if m.reason != nil && c.Reason != *m.reason {
+		diffs = append(diffs, fmt.Sprintf("reason:  want %s, got %s", *m.reason, c.Reason))
+	}
+	if m.message != nil && c.Message != *m.message {
+		diffs = append(diffs, fmt.Sprintf("message: want %q, got %q", *m.message, c.Message))
+	}
+	if m.messageContains != nil && !strings.Contains(c.Message, *m.messageContains) {
+		diffs = append(diffs, fmt.Sprintf("message: want it to contain %q, got %q", *m.messageContains, c.Message))
+	}
+	if m.generation != nil && c.ObservedGeneration != *m.generation {
+		diffs = append(diffs, fmt.Sprintf("observed generation: want %d, got %d", *m.generation, c.ObservedGeneration))
+	}
+	return diffs
+}
+
+// PhaseMatcher matches a condition set from which a [conditions.Stages]
+// derives a phase. Build one with [HavePhase].
+type PhaseMatcher struct {
+	stages conditions.Stages
+	phase  string
+}
+
+var _ types.GomegaMatcher = PhaseMatcher{}
+
+// HavePhase matches a condition set that stages reads as phase, through
+// [conditions.Stages.Phase].
+func HavePhase(stages conditions.Stages, phase string) PhaseMatcher {
+	return PhaseMatcher{stages: stages, phase: phase}
+}
+
+// Match implements [types.GomegaMatcher].
+func (m PhaseMatcher) Match(actual any) (bool, error) {
+	conds, err := conditionsOf(actual)
+	if err != nil {
+		return false, err
+	}
+	return m.stages.Phase(conds) == m.phase, nil
+}
+
+// FailureMessage implements [types.GomegaMatcher].
+func (m PhaseMatcher) FailureMessage(actual any) string {
+	conds, _ := conditionsOf(actual)
+	return fmt.Sprintf("expected phase %s, got %s\n%s",
+		m.phase, m.stages.Phase(conds), Describe(conds, m.notTrue(conds)...))
+}
+
+// NegatedFailureMessage implements [types.GomegaMatcher].
+func (m PhaseMatcher) NegatedFailureMessage(actual any) string {
+	conds, _ := conditionsOf(actual)
+	return fmt.Sprintf("expected a phase other than %s\n%s", m.phase, Describe(conds, m.notTrue(conds)...))
+}
+
+// notTrue returns the stages that are not passing: the ones a phase other than
+// the expected one comes from.
+func (m PhaseMatcher) notTrue(conds []metav1.Condition) []string {
+	var stuck []string
+	for _, t := range m.stages.Types {
+		if !m.stages.Passing(conds, t) {
+			stuck = append(stuck, t)
+		}
+	}
+	return stuck
+}
+
+// Describe renders conds as a table, one condition per line, with the types in
+// marked picked out. It is what the matchers here print on failure, exported
+// for the tests that build a failure message of their own.
+func Describe(conds []metav1.Condition, marked ...string) string {
+	if len(conds) == 0 {
+		return "conditions: none\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("conditions:\n")
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, c := range conds {
+		mark := " "
+		for _, t := range marked {
+			if c.Type == t {
+				mark = ">"
+				break
+			}
+		}
+		fmt.Fprintf(w, "  %s %s\t%s\t%s\tgen=%d\t%q\n", mark, c.Type, c.Status, c.Reason, c.ObservedGeneration, c.Message)
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// conditionsOf is what the matchers accept as actual.
+func conditionsOf(actual any) ([]metav1.Condition, error) {
+	switch a := actual.(type) {
+	case []metav1.Condition:
+		return a, nil
+	case *[]metav1.Condition:
+		if a == nil {
+			return nil, nil
+		}
+		return *a, nil
+	}
+	conds, err := conditions.ConditionsOf(actual)
+	if err != nil {
+		return nil, fmt.Errorf("conditionstest: cannot read conditions: %w", err)
+	}
+	return conds, nil
+}
@@ -0,0 +1,138 @@
+package conditionstest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+	"github.com/deckhouse/sds-common-lib/conditions/conditionstest"
+)
+
+type object struct {
+	metav1.ObjectMeta
+	Status *struct {
+		Conditions []metav1.Condition
+	}
+}
+
+func failed() (conditions.Stages, []metav1.Condition) {
+	s := conditions.Stages{Types: []string{"A", "B"}}
+	var conds []metav1.Condition
+	s.Fail(&conds, 3, "A", errors.New("boom"))
+	return s, conds
+}
+
+func TestGomega(t *testing.T) {
+	g := NewWithT(t)
+	s, conds := failed()
+
+	g.Expect(conds).To(conditionstest.HaveCondition("A").
+		WithStatus(metav1.ConditionFalse).
+		WithReason(conditions.ReasonReconcileFailed).
+		WithMessage("boom").
+		WithObservedGeneration(3))
+	g.Expect(&conds).To(conditionstest.HaveCondition("B").WithMessageContaining("waiting for A"))
+	g.Expect(conds).NotTo(conditionstest.HaveCondition("C"))
+	g.Expect(conds).NotTo(conditionstest.BeReadyFor(3))
+	g.Expect(conds).To(conditionstest.HavePhase(s, conditions.PhaseError))
+
+	s.Pass(&conds, 4, "A", "")
+	s.Pass(&conds, 4, "B", "")
+	s.SetReady(&conds, 4, "")
+	g.Expect(conds).To(conditionstest.BeReadyFor(4))
+	g.Expect(conds).NotTo(conditionstest.BeReadyFor(5))
+	g.Expect(conds).To(conditionstest.HavePhase(s, conditions.PhaseReady))
+}
+
+func TestMatchersTakeAnObject(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &object{}
+	g.Expect(obj).NotTo(conditionstest.HaveCondition(conditions.TypeReady))
+
+	g.Expect(conditions.Edit(obj, func(conds *[]metav1.Condition) {
+		conditions.Set(conds, conditions.Ready(1, nil))
+	})).To(Succeed())
+	g.Expect(obj).To(conditionstest.BeReadyFor(1))
+
+	_, err := conditionstest.HaveCondition("A").Match(42)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFailureMessagePrintsTheConditionSet(t *testing.T) {
+	_, conds := failed()
+	m := conditionstest.HaveCondition("A").
+		WithStatus(metav1.ConditionTrue).
+		WithReason(conditions.ReasonReconciled)
+
+	got := m.FailureMessage(conds)
+	for _, want := range []string{
+		"expected A to match status True, reason Reconciled:",
+		"status:  want True, got False",
+		"reason:  want Reconciled, got ReconcileFailed",
+		`> A      False  ReconcileFailed       gen=3  "boom"`,
+		`  B      False  WaitingForDependency  gen=3  "waiting for A"`,
+		`  Ready  False  ReconcileFailed       gen=3  "A: boom"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("the failure message lacks %q:\n%s", want, got)
+		}
+	}
+
+	got = conditionstest.HaveCondition("C").FailureMessage(conds)
+	if !strings.HasPrefix(got, "expected a C condition, there is none\nconditions:\n") {
+		t.Errorf("FailureMessage for a missing condition:\n%s", got)
+	}
+}
+
+func TestPhaseFailureMessageMarksTheStagesNotTrue(t *testing.T) {
+	s, conds := failed()
+
+	got := conditionstest.HavePhase(s, conditions.PhaseReady).FailureMessage(conds)
+	for _, want := range []string{"expected phase Ready, got Error", "> A", "> B", "  Ready"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("the failure message lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+// recorder stands in for a *testing.T, to see the testify side fail.
+type recorder struct {
+	errors []string
+	failed bool
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+func (r *recorder) FailNow() { r.failed = true }
+
+func TestAssertAndRequire(t *testing.T) {
+	_, conds := failed()
+
+	rec := &recorder{}
+	if !conditionstest.Assert(rec, conds, conditionstest.HaveCondition("A").WithStatus(metav1.ConditionFalse)) {
+		t.Errorf("Assert failed on a match: %v", rec.errors)
+	}
+
+	if conditionstest.Assert(rec, conds, conditionstest.BeReadyFor(3), "after the first pass") {
+		t.Error("Assert passed on a mismatch")
+	}
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "after the first pass") ||
+		!strings.Contains(rec.errors[0], "status:  want True, got False") {
+		t.Errorf("errors = %q", rec.errors)
+	}
+	if rec.failed {
+		t.Error("Assert must not stop the test")
+	}
+
+	conditionstest.Require(rec, conds, conditionstest.BeReadyFor(3))
+	if !rec.failed {
+		t.Error("Require must stop the test")
+	}
+}
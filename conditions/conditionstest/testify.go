@@ -0,0 +1,39 @@
+package conditionstest
+
+import (
+	"github.com/onsi/gomega/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Assert reports a failure on t unless matcher matches actual, and returns
+// whether it did, the way the testify assert functions do. matcher is any
+// Gomega matcher, the ones here included:
+//
+//	conditionstest.Assert(t, conds, conditionstest.HaveCondition("A").WithStatus(metav1.ConditionTrue))
+func Assert(t assert.TestingT, actual any, matcher types.GomegaMatcher, msgAndArgs ...any) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	ok, err := matcher.Match(actual)
+	if err != nil {
+		return assert.Fail(t, err.Error(), msgAndArgs...)
+	}
+	if !ok {
+		return assert.Fail(t, matcher.FailureMessage(actual), msgAndArgs...)
+	}
+	return true
+}
+
+// Require is [Assert] that stops the test on a failure, the way the testify
+// require functions do.
+func Require(t require.TestingT, actual any, matcher types.GomegaMatcher, msgAndArgs ...any) {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if !Assert(t, actual, matcher, msgAndArgs...) {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,164 @@
+package conditions
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// TeardownOrder returns the stages in the order a deletion tears them down:
+// Types reversed, so that a stage goes before the ones it was built on. With
+// [Stages.Requires] set the reversal is still a valid order, since Types lists
+// every stage after the ones it requires.
+func (s Stages) TeardownOrder() []string {
+	order := make([]string, len(s.Types))
+	for i, t := range s.Types {
+		order[len(s.Types)-1-i] = t
+	}
+	return order
+}
+
+// Teardown records the outcome of tearing one stage down and reports whether
+// the deletion may proceed to the next one in [Stages.TeardownOrder]. It is
+// [Stages.Advance] for the way back:
+//
+//	for _, stage := range s.TeardownOrder() {
+//		done, err := teardown[stage](ctx, obj)
+//		if !s.Teardown(conds, obj.Generation, stage, done, "", err) {
+//			return
+//		}
+//	}
+//	removed, err := s.RemoveFinalizer(ctx, cl, obj, *conds, finalizer)
+//
+// It exists because every controller with a finalizer had been hand-rolling
+// this walk, and none of them said where it was: a resource stuck deleting
+// showed the conditions of the last successful create, all True, next to a
+// deletion timestamp hours old.
+//
+//   - A stage torn down has its condition removed: there is nothing left for
+//     it to report on. The removal is still a transition: its series leave
+//     [Stages.Metrics], and it is logged to [Stages.History] and emitted as an
+//     Event.
+//   - A stage still being torn down is False with the [Stages.Deleting]
+//     reason and message.
+//   - A stage whose teardown failed is False with the [Stages.Failed] reason
+//     and the error text, the same as a failure on the way up.
+//
+// Every call rewrites the aggregate as False with the Deleting reason, naming
+// the stage the teardown is at, which is what makes "stuck deleting on X" read
+// the way "stuck creating X" does, and what [Stages.Phase] reads as
+// [PhaseTerminating].
+//
+// err takes precedence over done.
+func (s Stages) Teardown(
+	conds *[]metav1.Condition,
+	generation int64,
+	stage string,
+	done bool,
+	message string,
+	err error,
+) bool {
+	switch {
+	case err != nil:
+		s.set(conds, generation, stage, metav1.ConditionFalse, s.failed(), err.Error())
+	case !done:
+		s.set(conds, generation, stage, metav1.ConditionFalse, s.deleting(), message)
+	default:
+		s.unpublish(conds, generation, stage)
+	}
+
+	s.publish(conds, s.terminatingCondition(*conds, generation), true)
+	return err == nil && done
+}
+
+// terminatingCondition is the aggregate while the resource is torn down. The
+// stage the teardown is at is the last one in Types that still has a
+// condition: the walk goes backwards and removes them as it goes.
+func (s Stages) terminatingCondition(conds []metav1.Condition, generation int64) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               s.readyType(),
+		Status:             metav1.ConditionFalse,
+		Reason:             s.deleting(),
+		Message:            "torn down",
+		ObservedGeneration: generation,
+	}
+
+	for i := len(s.Types) - 1; i >= 0; i-- {
+		c := Get(conds, s.Types[i])
+		if c == nil {
+			continue
+		}
+		msg := "deleting " + c.Type
+		if s.notPassing(c) && c.Message != "" {
+			msg += ": " + c.Message
+		}
+		cond.Message = s.sanitize(msg)
+		break
+	}
+	return cond
+}
+
+// TornDown reports whether every stage has been torn down by
+// [Stages.Teardown], which is when the finalizer may go.
+func (s Stages) TornDown(conds []metav1.Condition) bool {
+	for _, t := range s.Types {
+		if Get(conds, t) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// AddFinalizer adds finalizer to obj on the server, if it is not there yet.
+//
+// An object that is already being deleted is left alone: the API server rejects
+// a new finalizer on it, and a resource whose create never got as far as adding
+// one has nothing of its own to tear down.
+//
+// The patch carries obj's resourceVersion, so a finalizer list that another
+// actor changed since obj was read fails with a conflict rather than being
+// overwritten; requeue on it. obj is updated to what was written.
+func AddFinalizer(ctx context.Context, cl client.Client, obj client.Object, finalizer string) error {
+	if !obj.GetDeletionTimestamp().IsZero() || controllerutil.ContainsFinalizer(obj, finalizer) {
+		return nil
+	}
+
+	before := obj.DeepCopyObject().(client.Object)
+	controllerutil.AddFinalizer(obj, finalizer)
+	return cl.Patch(ctx, obj, client.MergeFromWithOptions(before, client.MergeFromWithOptimisticLock{}))
+}
+
+// RemoveFinalizer removes finalizer from obj on the server once every stage has
+// been torn down, and reports whether obj is free to go — the finalizer is
+// gone, whether it was removed here or never there.
+//
+// conds are the conditions [Stages.Teardown] wrote. Checking them here rather
+// than leaving it to the caller is the point: a finalizer removed while a stage
+// still holds a resource is the leak the finalizer was there to prevent.
+//
+// The patch is guarded by obj's resourceVersion the same way as
+// [AddFinalizer]'s.
+func (s Stages) RemoveFinalizer(
+	ctx context.Context,
+	cl client.Client,
+	obj client.Object,
+	conds []metav1.Condition,
+	finalizer string,
+) (bool, error) {
+	if !controllerutil.ContainsFinalizer(obj, finalizer) {
+		return true, nil
+	}
+	if !s.TornDown(conds) {
+		return false, nil
+	}
+
+	before := obj.DeepCopyObject().(client.Object)
+	controllerutil.RemoveFinalizer(obj, finalizer)
+	err := cl.Patch(ctx, obj, client.MergeFromWithOptions(before, client.MergeFromWithOptimisticLock{}))
+	if client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,207 @@
+package conditions_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+const finalizer = "storage.deckhouse.io/sds-test"
+
+func TestTeardownOrder(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A", "B", "C"}}
+	if got := s.TeardownOrder(); !slices.Equal(got, []string{"C", "B", "A"}) {
+		t.Errorf("TeardownOrder() = %v", got)
+	}
+}
+
+func TestTeardown(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A", "B", "C"}}
+	conds := passed(s)
+
+	if s.Teardown(&conds, 1, "C", false, "detaching the volume", nil) {
+		t.Fatal("a stage still being torn down must stop the walk")
+	}
+	c := find(t, conds, "C")
+	if c.Status != metav1.ConditionFalse || c.Reason != conditions.ReasonDeleting {
+		t.Errorf("C = %+v, want False/%s", c, conditions.ReasonDeleting)
+	}
+	ready := find(t, conds, conditions.TypeReady)
+	if ready.Reason != conditions.ReasonDeleting || ready.Message != "deleting C: detaching the volume" {
+		t.Errorf("Ready = %s %q", ready.Reason, ready.Message)
+	}
+	if got := s.Phase(conds); got != conditions.PhaseTerminating {
+		t.Errorf("Phase = %q, want %q", got, conditions.PhaseTerminating)
+	}
+
+	if !s.Teardown(&conds, 1, "C", true, "", nil) {
+		t.Fatal("a stage torn down must let the walk proceed")
+	}
+	if got := types(conds); !slices.Equal(got, []string{"A", "B", conditions.TypeReady}) {
+		t.Errorf("conditions = %v, want C gone", got)
+	}
+	if got := find(t, conds, conditions.TypeReady).Message; got != "deleting B" {
+		t.Errorf("Ready message = %q, want the next stage named", got)
+	}
+	if s.TornDown(conds) {
+		t.Error("TornDown with A and B still there")
+	}
+
+	s.Teardown(&conds, 1, "B", true, "", nil)
+	s.Teardown(&conds, 1, "A", true, "", nil)
+	if !s.TornDown(conds) {
+		t.Errorf("TornDown = false with conditions %v", types(conds))
+	}
+	if got := s.Phase(conds); got != conditions.PhaseTerminating {
+		t.Errorf("Phase = %q, want %q until the object is gone", got, conditions.PhaseTerminating)
+	}
+}
+
+// A teardown that fails is still a deletion: the phase stays Terminating, and
+// the aggregate names the stage it is stuck on and why.
+func TestTeardownFailure(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A", "B", "C"}}
+	conds := passed(s)
+	s.Teardown(&conds, 1, "C", true, "", nil)
+
+	if s.Teardown(&conds, 1, "B", false, "", errors.New("lvremove: volume is in use")) {
+		t.Fatal("a failed teardown must stop the walk")
+	}
+	if got := find(t, conds, "B").Reason; got != conditions.ReasonReconcileFailed {
+		t.Errorf("B reason = %q, want %q", got, conditions.ReasonReconcileFailed)
+	}
+	if got := find(t, conds, conditions.TypeReady).Message; got != "deleting B: lvremove: volume is in use" {
+		t.Errorf("Ready message = %q", got)
+	}
+	if got := s.Phase(conds); got != conditions.PhaseTerminating {
+		t.Errorf("Phase = %q, want %q", got, conditions.PhaseTerminating)
+	}
+}
+
+// A stage torn down leaves the way any other transition does: its series go,
+// and the removal is logged and emitted.
+func TestTeardownPublishesTheRemoval(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A", "B", "C"}}
+	conds := passed(s)
+	m := conditions.NewMetrics()
+	rec := record.NewFakeRecorder(100)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var history conditions.History
+	s.Metrics = m.For("Widget", newPod())
+	s.History = &history
+	s.Recorder = conditions.EventsFor(rec, newPod())
+	s.Now = func() time.Time { return now }
+
+	s.Teardown(&conds, 1, "C", false, "detaching the volume", nil)
+	drain(rec)
+	now = now.Add(10 * time.Minute)
+	s.Teardown(&conds, 1, "C", true, "", nil)
+
+	const status = `
+# HELP sds_condition_status The status and reason each condition of a resource currently holds, as 1.
+# TYPE sds_condition_status gauge
+sds_condition_status{kind="Widget",name="target",namespace="default",reason="Deleting",status="False",type="Ready"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(status), "sds_condition_status"); err != nil {
+		t.Errorf("C is torn down, yet still exported: %v", err)
+	}
+	deleting := fmt.Sprintf(nonTrue, "False", "C", 600)
+	if err := testutil.CollectAndCompare(m, strings.NewReader(deleting), "sds_condition_non_true_duration_seconds"); err != nil {
+		t.Errorf("expected the ten minutes C spent deleting observed: %v", err)
+	}
+
+	log := history.Of("C")
+	if len(log) == 0 {
+		t.Fatal("the removal of C was not logged")
+	}
+	if last := log[len(log)-1]; last.Status != metav1.ConditionUnknown ||
+		last.Reason != conditions.ReasonDeleting || !last.LastTransitionTime.Time.Equal(now) {
+		t.Errorf("last transition of C = %+v, want Unknown/%s at %s", last, conditions.ReasonDeleting, now)
+	}
+
+	if got := drain(rec); len(got) == 0 || got[0] != "Normal Deleting C is torn down" {
+		t.Errorf("events = %q, want the removal of C first", got)
+	}
+}
+
+func TestAddFinalizer(t *testing.T) {
+	cl := newClient(t, newPod())
+	pod := readPod(t, cl)
+
+	if err := conditions.AddFinalizer(context.Background(), cl, pod, finalizer); err != nil {
+		t.Fatalf("AddFinalizer: %v", err)
+	}
+	if err := conditions.AddFinalizer(context.Background(), cl, pod, finalizer); err != nil {
+		t.Fatalf("AddFinalizer again: %v", err)
+	}
+	if got := readPod(t, cl).Finalizers; !slices.Equal(got, []string{finalizer}) {
+		t.Errorf("finalizers = %v", got)
+	}
+}
+
+func TestAddFinalizerLeavesADeletingObjectAlone(t *testing.T) {
+	pod := newPod()
+	pod.Finalizers = []string{"someone.else/finalizer"}
+	cl := newClient(t, pod)
+	if err := cl.Delete(context.Background(), pod); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	pod = readPod(t, cl)
+	if err := conditions.AddFinalizer(context.Background(), cl, pod, finalizer); err != nil {
+		t.Fatalf("AddFinalizer: %v", err)
+	}
+	if got := readPod(t, cl).Finalizers; slices.Contains(got, finalizer) {
+		t.Errorf("finalizers = %v, want ours not added", got)
+	}
+}
+
+func TestRemoveFinalizer(t *testing.T) {
+	pod := newPod()
+	pod.Finalizers = []string{finalizer}
+	cl := newClient(t, pod)
+	if err := cl.Delete(context.Background(), pod); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	pod = readPod(t, cl)
+
+	s := conditions.Stages{Types: []string{"A", "B", "C"}}
+	conds := passed(s)
+	s.Teardown(&conds, 1, "C", true, "", nil)
+
+	removed, err := s.RemoveFinalizer(context.Background(), cl, pod, conds, finalizer)
+	if err != nil || removed {
+		t.Fatalf("RemoveFinalizer with stages left = %v, %v; want it kept", removed, err)
+	}
+	if got := readPod(t, cl).Finalizers; !slices.Equal(got, []string{finalizer}) {
+		t.Errorf("finalizers = %v, want it kept", got)
+	}
+
+	s.Teardown(&conds, 1, "B", true, "", nil)
+	s.Teardown(&conds, 1, "A", true, "", nil)
+	removed, err = s.RemoveFinalizer(context.Background(), cl, pod, conds, finalizer)
+	if err != nil || !removed {
+		t.Fatalf("RemoveFinalizer once torn down = %v, %v", removed, err)
+	}
+	// The last finalizer gone, the fake client deletes the object for good.
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(pod), &corev1.Pod{}); err == nil {
+		t.Error("the pod should be gone")
+	}
+
+	removed, err = s.RemoveFinalizer(context.Background(), cl, pod, conds, finalizer)
+	if err != nil || !removed {
+		t.Errorf("RemoveFinalizer once gone = %v, %v", removed, err)
+	}
+}
@@ -0,0 +1,150 @@
+package conditions
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Verdict is what [WaitFor] found on a dependency.
+type Verdict string
+
+const (
+	// DependencyReady means the condition is True for the dependency's
+	// current generation.
+	DependencyReady Verdict = "Ready"
+	// DependencyStale means the dependency's controller has no verdict on its
+	// current spec: the condition is absent, Unknown, or was recorded for an
+	// older generation.
+	DependencyStale Verdict = "Stale"
+	// DependencyFailed means the condition is False for the dependency's
+	// current generation. [Dependency.Message] carries what it says.
+	DependencyFailed Verdict = "Failed"
+	// DependencyMissing means the dependency does not exist.
+	DependencyMissing Verdict = "Missing"
+)
+
+// Dependency is the outcome of [WaitFor]: a verdict, and a message naming the
+// dependency that is ready to go into a condition as it is.
+type Dependency struct {
+	Verdict Verdict
+	Message string
+}
+
+// WaitFor reads the object at key into obj and reports whether it is ready for
+// a resource that depends on it, going by its conditionType condition.
+//
+// It is the check every controller that waits on another resource had been
+// writing by hand, and the one most of them got subtly wrong: a True condition
+// the dependency's controller recorded for an older generation is not a ready
+// dependency — the spec has moved on and nobody has looked at it yet — and that
+// reads as [DependencyStale] here, the same as no verdict at all.
+//
+// obj is only used for its type; what it holds on return is whatever was read.
+// A dependency that does not exist is a verdict rather than an error, since
+// waiting for it to be created is an ordinary thing for a reconcile to do; the
+// error is for a read that did not get an answer at all.
+//
+// The dependency's conditions are read through its unstructured form, so any
+// kind with a standard status.conditions works without this package knowing
+// its status type. r may be a cached client: the generation check is what keeps
+// a lagging cache from reading as ready.
+func WaitFor(
+	ctx context.Context,
+	r client.Reader,
+	key client.ObjectKey,
+	obj client.Object,
+	conditionType string,
+) (Dependency, error) {
+	name := dependencyName(obj, key)
+
+	if err := r.Get(ctx, key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return Dependency{Verdict: DependencyMissing, Message: name + " does not exist"}, nil
+		}
+		return Dependency{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return Dependency{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+	conds, err := unstructuredConditions(u)
+	if err != nil {
+		return Dependency{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	c := Get(conds, conditionType)
+	switch {
+	case c == nil || c.Status == metav1.ConditionUnknown:
+		return Dependency{
+			Verdict: DependencyStale,
+			Message: fmt.Sprintf("%s has no %s verdict yet", name, conditionType),
+		}, nil
+	case c.ObservedGeneration < obj.GetGeneration():
+		return Dependency{
+			Verdict: DependencyStale,
+			Message: fmt.Sprintf("%s has not observed generation %d", name, obj.GetGeneration()),
+		}, nil
+	case c.Status == metav1.ConditionFalse:
+		msg := fmt.Sprintf("%s is not %s", name, conditionType)
+		if c.Message != "" {
+			msg += ": " + c.Message
+		}
+		return Dependency{Verdict: DependencyFailed, Message: msg}, nil
+	}
+	return Dependency{Verdict: DependencyReady, Message: fmt.Sprintf("%s is %s", name, conditionType)}, nil
+}
+
+// dependencyName names the dependency in messages as `Kind namespace/name`. The
+// kind comes off the Go type when obj carries no TypeMeta, which a typed object
+// read through a client usually does not.
+func dependencyName(obj client.Object, key client.ObjectKey) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		t := reflect.TypeOf(obj)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		kind = t.Name()
+	}
+	return kind + " " + key.String()
+}
+
+// Await records the outcome of waiting on a dependency as the outcome of stage,
+// and reports whether the reconcile may proceed. dep and err are what [WaitFor]
+// returned:
+//
+//   - a ready dependency passes the stage;
+//   - a missing, stale or failed one has the stage wait with the
+//     [Stages.Blocked] reason, and the dependency's own message;
+//   - err fails the stage.
+//
+// A failed dependency is waited on rather than failed: the dependency not being
+// ready is not this resource's reconcile failing, it clears by itself once the
+// dependency recovers, and an Error phase on both would page twice for one
+// fault. The failure that does belong here is not being able to read the
+// dependency at all.
+func (s Stages) Await(
+	conds *[]metav1.Condition,
+	generation int64,
+	stage string,
+	dep Dependency,
+	err error,
+) bool {
+	switch {
+	case err != nil:
+		s.Fail(conds, generation, stage, err)
+	case dep.Verdict == DependencyReady:
+		s.Pass(conds, generation, stage, dep.Message)
+		return true
+	default:
+		s.Wait(conds, generation, stage, s.blocked(), dep.Message)
+	}
+	return false
+}
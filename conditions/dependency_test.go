@@ -0,0 +1,187 @@
+package conditions_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+var depKey = client.ObjectKey{Name: "target", Namespace: "default"}
+
+func TestWaitFor(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		conds   []corev1.PodCondition
+		want    conditions.Verdict
+		message string
+	}{
+		{
+			name:    "a True condition is ready",
+			conds:   []corev1.PodCondition{{Type: "Ready", Status: corev1.ConditionTrue}},
+			want:    conditions.DependencyReady,
+			message: "Pod default/target is Ready",
+		},
+		{
+			name:    "a False condition has failed, with what it says",
+			conds:   []corev1.PodCondition{{Type: "Ready", Status: corev1.ConditionFalse, Message: "disk full"}},
+			want:    conditions.DependencyFailed,
+			message: "Pod default/target is not Ready: disk full",
+		},
+		{
+			name:    "an Unknown condition is no verdict yet",
+			conds:   []corev1.PodCondition{{Type: "Ready", Status: corev1.ConditionUnknown}},
+			want:    conditions.DependencyStale,
+			message: "Pod default/target has no Ready verdict yet",
+		},
+		{
+			name:    "an absent condition is no verdict yet",
+			want:    conditions.DependencyStale,
+			message: "Pod default/target has no Ready verdict yet",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := newPod()
+			pod.Status.Conditions = tc.conds
+			cl := newClient(t, pod)
+
+			dep, err := conditions.WaitFor(context.Background(), cl, depKey, &corev1.Pod{}, "Ready")
+			if err != nil {
+				t.Fatalf("WaitFor: %v", err)
+			}
+			if dep.Verdict != tc.want {
+				t.Errorf("verdict = %q, want %q", dep.Verdict, tc.want)
+			}
+			if dep.Message != tc.message {
+				t.Errorf("message = %q, want %q", dep.Message, tc.message)
+			}
+		})
+	}
+}
+
+func TestWaitForAMissingDependencyIsAVerdict(t *testing.T) {
+	dep, err := conditions.WaitFor(context.Background(), newClient(t), depKey, &corev1.Pod{}, "Ready")
+	if err != nil {
+		t.Fatalf("a dependency not created yet is something to wait for, not an error: %v", err)
+	}
+	if dep.Verdict != conditions.DependencyMissing {
+		t.Errorf("verdict = %q, want %q", dep.Verdict, conditions.DependencyMissing)
+	}
+}
+
+// The case WaitFor exists for: a True condition left over from before the
+// dependency's spec changed says nothing about the spec it has now.
+func TestWaitForATrueConditionFromAnOlderGenerationIsStale(t *testing.T) {
+	pod := newPod()
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(pod).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				// Pods carry no observedGeneration on their conditions, so
+				// the generation the dependency's controller recorded is
+				// stood in for by the object's own moving past it.
+				obj.SetGeneration(4)
+				obj.(*corev1.Pod).Status.Conditions = []corev1.PodCondition{{Type: "Ready", Status: corev1.ConditionTrue}}
+				return nil
+			},
+		}).
+		Build()
+
+	dep, err := conditions.WaitFor(context.Background(), cl, depKey, &corev1.Pod{}, "Ready")
+	if err != nil {
+		t.Fatalf("WaitFor: %v", err)
+	}
+	if dep.Verdict != conditions.DependencyStale {
+		t.Errorf("verdict = %q, want %q", dep.Verdict, conditions.DependencyStale)
+	}
+	if !strings.Contains(dep.Message, "generation 4") {
+		t.Errorf("the message should name the generation, got %q", dep.Message)
+	}
+}
+
+func TestWaitForPropagatesAReadError(t *testing.T) {
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+				return apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "target", errors.New("rbac"))
+			},
+		}).
+		Build()
+
+	_, err := conditions.WaitFor(context.Background(), cl, depKey, &corev1.Pod{}, "Ready")
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("expected the Forbidden to come through, got %v", err)
+	}
+}
+
+func TestAwait(t *testing.T) {
+	s := conditions.Stages{Types: []string{"DependencyReady", "Published"}}
+
+	t.Run("a ready dependency passes the stage", func(t *testing.T) {
+		var conds []metav1.Condition
+		if !s.Await(&conds, 1, "DependencyReady", conditions.Dependency{
+			Verdict: conditions.DependencyReady, Message: "Pod default/target is Ready",
+		}, nil) {
+			t.Fatal("Await should have let the reconcile proceed")
+		}
+		if c := find(t, conds, "DependencyReady"); c.Status != metav1.ConditionTrue || c.Message != "Pod default/target is Ready" {
+			t.Errorf("DependencyReady = %+v", c)
+		}
+	})
+
+	// The dependency not being ready is not this reconcile failing, and an
+	// Error phase on both resources would page twice for one fault.
+	for _, v := range []conditions.Verdict{conditions.DependencyFailed, conditions.DependencyStale, conditions.DependencyMissing} {
+		t.Run("a "+string(v)+" dependency is waited on", func(t *testing.T) {
+			var conds []metav1.Condition
+			if s.Await(&conds, 1, "DependencyReady", conditions.Dependency{Verdict: v, Message: "Pod default/target: " + string(v)}, nil) {
+				t.Fatal("Await should have stopped the reconcile")
+			}
+			c := find(t, conds, "DependencyReady")
+			if c.Status != metav1.ConditionFalse || c.Reason != conditions.ReasonWaitingForDependency {
+				t.Errorf("DependencyReady = %+v, want False/%s", c, conditions.ReasonWaitingForDependency)
+			}
+			if c.Message != "Pod default/target: "+string(v) {
+				t.Errorf("the message should name the dependency, got %q", c.Message)
+			}
+			if got := s.Phase(conds); got != conditions.PhaseInProgress {
+				t.Errorf("Phase = %q, want %q", got, conditions.PhaseInProgress)
+			}
+		})
+	}
+
+	t.Run("a read error fails the stage", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Await(&conds, 1, "DependencyReady", conditions.Dependency{}, errors.New("reading Pod default/target: forbidden"))
+
+		if got := find(t, conds, "DependencyReady").Reason; got != conditions.ReasonReconcileFailed {
+			t.Errorf("reason = %q, want %q", got, conditions.ReasonReconcileFailed)
+		}
+	})
+
+	t.Run("it waits with the caller's blocked reason", func(t *testing.T) {
+		s := conditions.Stages{Types: []string{"DependencyReady", "Published"}, Blocked: "Pending"}
+		var conds []metav1.Condition
+		s.Await(&conds, 1, "DependencyReady", conditions.Dependency{Verdict: conditions.DependencyMissing}, nil)
+
+		if got := find(t, conds, "DependencyReady").Reason; got != "Pending" {
+			t.Errorf("reason = %q, want %q", got, "Pending")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package conditions
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Recorder is told about the condition transitions [Stages] makes.
+//
+// It is the object-less half of a record.EventRecorder: a Stages value is
+// declared once per controller and the resource changes on every reconcile, so
+// the object is bound by [EventsFor] at the call site rather than carried here.
+// A module that does not emit Events can implement it to log or count instead.
+type Recorder interface {
+	Event(eventtype, reason, message string)
+}
+
+// EventsFor binds rec to the resource the stages are reconciled on, so that the
+// transitions [Stages] records show up in `kubectl describe` for it:
+//
+//	s := r.stages
+//	s.Recorder = conditions.EventsFor(r.recorder, obj)
+func EventsFor(rec record.EventRecorder, obj runtime.Object) Recorder {
+	return objectRecorder{rec: rec, obj: obj}
+}
+
+type objectRecorder struct {
+	rec record.EventRecorder
+	obj runtime.Object
+}
+
+func (r objectRecorder) Event(eventtype, reason, message string) {
+	r.rec.Event(r.obj, eventtype, reason, message)
+}
+
+// record emits an Event for cond, which changed the status of its type. A
+// condition published for the first time is a transition too: absent is a
+// status of its own, the one [IsUnknown] reads.
+//
+// conds is what cond was written into. The aggregate carries the failed reason
+// for any stage that is False, so whether it warns is read off the stages — a
+// resource waiting on a dependency is not failing.
+func (s Stages) record(conds []metav1.Condition, cond metav1.Condition) {
+	if s.Recorder == nil {
+		return
+	}
+
+	failing := s.notPassing(&cond) && cond.Reason == s.failed()
+	switch cond.Type {
+	case s.readyType():
+		failing = s.Phase(conds) == PhaseError
+	case s.healthyType():
+		// What the advisory aggregate exists to say is a warning.
+		failing = len(s.Advisory) > 0 && cond.Status == metav1.ConditionFalse
+	}
+
+	eventtype := corev1.EventTypeNormal
+	if failing {
+		eventtype = corev1.EventTypeWarning
+	}
+
+	msg := cond.Type + " is " + string(cond.Status)
+	if cond.Message != "" {
+		msg += ": " + cond.Message
+	}
+	s.Recorder.Event(eventtype, cond.Reason, msg)
+}
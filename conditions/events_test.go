@@ -0,0 +1,119 @@
+package conditions_test
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+func drain(rec *record.FakeRecorder) []string {
+	var out []string
+	for {
+		select {
+		case e := <-rec.Events:
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func TestStagesRecordTransitions(t *testing.T) {
+	t.Run("a failure is a warning naming the stage", func(t *testing.T) {
+		rec := record.NewFakeRecorder(100)
+		s := conditions.Stages{Types: []string{"A", "B"}, Recorder: conditions.EventsFor(rec, newPod())}
+		var conds []metav1.Condition
+
+		s.Fail(&conds, 1, "A", errors.New("the backend is unreachable"))
+
+		got := drain(rec)
+		want := []string{
+			"Warning ReconcileFailed A is False: the backend is unreachable",
+			"Warning ReconcileFailed Ready is False: A: the backend is unreachable",
+		}
+		if len(got) != len(want) {
+			t.Fatalf("events = %q, want %q", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("event %d = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("a wait and a pass are normal", func(t *testing.T) {
+		rec := record.NewFakeRecorder(100)
+		s := conditions.Stages{Types: []string{"A"}, Recorder: conditions.EventsFor(rec, newPod())}
+		var conds []metav1.Condition
+
+		s.Wait(&conds, 1, "A", "Provisioning", "still coming up")
+		s.Pass(&conds, 1, "A", "done")
+		s.SetReady(&conds, 1, "all stages reconciled")
+
+		got := drain(rec)
+		want := []string{
+			"Normal Provisioning A is False: still coming up",
+			"Normal ReconcileFailed Ready is False: A: still coming up",
+			"Normal Reconciled A is True: done",
+			"Normal Reconciled Ready is True: all stages reconciled",
+		}
+		if len(got) != len(want) {
+			t.Fatalf("events = %q, want %q", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("event %d = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	// A resync restates every stage. An Event per reconcile would bury the
+	// transitions the history exists to show.
+	t.Run("a status that stays put is not recorded", func(t *testing.T) {
+		rec := record.NewFakeRecorder(100)
+		s := conditions.Stages{Types: []string{"A"}, Recorder: conditions.EventsFor(rec, newPod())}
+		var conds []metav1.Condition
+
+		s.Pass(&conds, 1, "A", "done")
+		s.SetReady(&conds, 1, "")
+		drain(rec)
+
+		s.Pass(&conds, 2, "A", "done again")
+		s.SetReady(&conds, 2, "")
+
+		if got := drain(rec); len(got) != 0 {
+			t.Errorf("expected no events, got %q", got)
+		}
+	})
+
+	// The blocked stages say nothing the stage that blocked them did not.
+	t.Run("stages blocked downstream are not recorded", func(t *testing.T) {
+		rec := record.NewFakeRecorder(100)
+		s := conditions.Stages{Types: []string{"A", "B", "C"}, Recorder: conditions.EventsFor(rec, newPod())}
+		var conds []metav1.Condition
+
+		s.Wait(&conds, 1, "A", "", "")
+
+		for _, e := range drain(rec) {
+			if e == "Normal WaitingForDependency B is False: waiting for A" ||
+				e == "Normal WaitingForDependency C is False: waiting for A" {
+				t.Errorf("a blocked stage was recorded: %q", e)
+			}
+		}
+	})
+}
+
+func TestStagesWithoutARecorderStillWork(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A"}}
+	var conds []metav1.Condition
+
+	s.Fail(&conds, 1, "A", errors.New("boom"))
+
+	if find(t, conds, "A").Status != metav1.ConditionFalse {
+		t.Error("A should be False")
+	}
+}
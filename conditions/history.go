@@ -0,0 +1,160 @@
+package conditions
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultHistoryLimit is how many transitions per condition type a [History]
+// kept by [Stages] holds when [Stages.HistoryLimit] is not set.
+const DefaultHistoryLimit = 5
+
+// MaxHistoryMessageLen is the cap, in runes, on the message of a [Transition].
+//
+// It is far below [MaxMessageLen] because a History multiplies what it holds:
+// every stage keeps up to its limit of entries, all in the same object, and
+// etcd rejects an object over its size limit as a whole. A message at the
+// condition's cap in each of them would take the resource down with it. The
+// condition itself keeps the whole message; the log only needs enough of it
+// to tell one transition from another.
+const MaxHistoryMessageLen = 256
+
+// Transition is one entry of a [History]: a condition as it was at the moment
+// its status changed.
+type Transition struct {
+	// Type is the condition type that transitioned.
+	Type string `json:"type"`
+	// Status is the status it transitioned to.
+	Status metav1.ConditionStatus `json:"status"`
+	// Reason is the reason it carried when it did.
+	Reason string `json:"reason"`
+	// Message is the message it carried when it did.
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is when it did.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// ObservedGeneration is the generation it was recorded for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// History is a bounded log of condition transitions, meant to live in a status
+// field next to the conditions it describes:
+//
+//	type WidgetStatus struct {
+//		Conditions []metav1.Condition `json:"conditions,omitempty"`
+//		History    conditions.History `json:"conditionHistory,omitempty"`
+//	}
+//
+// It exists because a condition only holds its latest state. A stage that flaps
+// between False and True on every other reconcile looks healthy whenever someone
+// happens to look, and the evidence that it is not is gone by the time anyone
+// asks. Events carry the same information but expire after an hour; this does
+// not, and it travels with the resource.
+//
+// Entries are kept oldest first, and each condition type keeps at most its own
+// last N — a stage that flaps does not push the one transition of a quiet stage
+// out of the log.
+//
+// +listType=atomic
+type History []Transition
+
+// Record appends cond as the latest transition of its type, keeping at most
+// limit entries for that type, and reports whether anything changed. A limit
+// below one means [DefaultHistoryLimit].
+//
+// Recording is idempotent: a cond whose status and transition time are already
+// the latest entry of its type is not appended again. That is what keeps a
+// mutate function that [UpdateStatus] runs more than once — or a reconcile
+// that restates a stage — from logging the same transition twice.
+//
+// The message is redacted the same as the condition's, and truncated to
+// [MaxHistoryMessageLen].
+func (h *History) Record(cond metav1.Condition, limit int) bool {
+	if limit < 1 {
+		limit = DefaultHistoryLimit
+	}
+
+	if last := h.latest(cond.Type); last != nil &&
+		last.Status == cond.Status &&
+		last.LastTransitionTime.Equal(&cond.LastTransitionTime) {
+		return false
+	}
+
+	*h = append(*h, Transition{
+		Type:               cond.Type,
+		Status:             cond.Status,
+		Reason:             cond.Reason,
+		Message:            truncate(DefaultRedactor.Redact(cond.Message), MaxHistoryMessageLen),
+		LastTransitionTime: cond.LastTransitionTime,
+		ObservedGeneration: cond.ObservedGeneration,
+	})
+
+	h.trim(cond.Type, limit)
+	return true
+}
+
+// Of returns the transitions recorded for conditionType, oldest first.
+func (h History) Of(conditionType string) []Transition {
+	var out []Transition
+	for _, t := range h {
+		if t.Type == conditionType {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (h History) latest(conditionType string) *Transition {
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].Type == conditionType {
+			return &h[i]
+		}
+	}
+	return nil
+}
+
+// trim drops the oldest entries of conditionType beyond limit, leaving the
+// other types where they are.
+func (h *History) trim(conditionType string, limit int) {
+	excess := len(h.Of(conditionType)) - limit
+	if excess <= 0 {
+		return
+	}
+
+	kept := (*h)[:0]
+	for _, t := range *h {
+		if t.Type == conditionType && excess > 0 {
+			excess--
+			continue
+		}
+		kept = append(kept, t)
+	}
+	clear((*h)[len(kept):])
+	*h = kept
+}
+
+// DeepCopyInto copies the receiver into out. Written by hand rather than
+// generated, since this package is not an API package, so that a status type
+// embedding a History still gets a generated deepcopy of its own.
+func (in *Transition) DeepCopyInto(out *Transition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in History) DeepCopyInto(out *History) {
+	*out = in.DeepCopy()
+}
+
+// DeepCopy returns a copy of the receiver that shares nothing with it.
+func (in History) DeepCopy() History {
+	if in == nil {
+		return nil
+	}
+	out := make(History, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+package conditions_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+// widget stands in for a module's CRD: History is meant to sit in a status
+// type next to the conditions, and none of the built-in kinds has one.
+type widget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Status            widgetStatus `json:"status,omitempty"`
+}
+
+type widgetStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	History    conditions.History `json:"history,omitempty"`
+}
+
+func (w *widget) DeepCopyObject() runtime.Object {
+	out := &widget{TypeMeta: w.TypeMeta}
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	for _, c := range w.Status.Conditions {
+		out.Status.Conditions = append(out.Status.Conditions, *c.DeepCopy())
+	}
+	w.Status.History.DeepCopyInto(&out.Status.History)
+	return out
+}
+
+func at(sec int) metav1.Time {
+	return metav1.NewTime(time.Date(2025, 1, 1, 0, 0, sec, 0, time.UTC))
+}
+
+func ptr(t metav1.Time) *metav1.Time { return &t }
+
+func TestHistoryRecord(t *testing.T) {
+	t.Run("it keeps the last N per type, oldest first", func(t *testing.T) {
+		var h conditions.History
+		h.Record(metav1.Condition{Type: "Quiet", Status: metav1.ConditionTrue, LastTransitionTime: at(0)}, 2)
+		for i := range 5 {
+			status := metav1.ConditionFalse
+			if i%2 == 1 {
+				status = metav1.ConditionTrue
+			}
+			h.Record(metav1.Condition{Type: "Flapping", Status: status, LastTransitionTime: at(i + 1)}, 2)
+		}
+
+		flapping := h.Of("Flapping")
+		if len(flapping) != 2 {
+			t.Fatalf("Flapping keeps %d entries, want 2", len(flapping))
+		}
+		if !flapping[0].LastTransitionTime.Equal(ptr(at(4))) || !flapping[1].LastTransitionTime.Equal(ptr(at(5))) {
+			t.Errorf("expected the two latest transitions oldest first, got %+v", flapping)
+		}
+
+		// The flapping stage must not push the only transition of the quiet
+		// one out of the log.
+		if len(h.Of("Quiet")) != 1 {
+			t.Errorf("Quiet lost its transition: %+v", h)
+		}
+	})
+
+	t.Run("recording the same transition twice keeps one entry", func(t *testing.T) {
+		var h conditions.History
+		c := metav1.Condition{Type: "A", Status: metav1.ConditionFalse, LastTransitionTime: at(1)}
+
+		if !h.Record(c, 0) {
+			t.Fatal("the first record should have changed the history")
+		}
+		if h.Record(c, 0) {
+			t.Error("the second record should have been a no-op")
+		}
+		if len(h) != 1 {
+			t.Errorf("got %d entries, want 1", len(h))
+		}
+	})
+
+	t.Run("it truncates a long message to its own cap", func(t *testing.T) {
+		var h conditions.History
+		h.Record(metav1.Condition{
+			Type:    "A",
+			Status:  metav1.ConditionFalse,
+			Message: strings.Repeat("x", conditions.MaxHistoryMessageLen+100),
+		}, 0)
+
+		if n := len([]rune(h[0].Message)); n != conditions.MaxHistoryMessageLen {
+			t.Errorf("message = %d runes, want %d", n, conditions.MaxHistoryMessageLen)
+		}
+		if !strings.HasSuffix(h[0].Message, "...") {
+			t.Errorf("message = %q, want the cut marked", h[0].Message)
+		}
+	})
+
+	t.Run("a message within the cap is kept whole", func(t *testing.T) {
+		var h conditions.History
+		msg := strings.Repeat("x", conditions.MaxHistoryMessageLen)
+		h.Record(metav1.Condition{Type: "A", Status: metav1.ConditionFalse, Message: msg}, 0)
+
+		if h[0].Message != msg {
+			t.Errorf("message = %d runes, want it untouched", len([]rune(h[0].Message)))
+		}
+	})
+}
+
+func TestStagesKeepTheHistory(t *testing.T) {
+	var h conditions.History
+	s := conditions.Stages{Types: []string{"A", "B"}, History: &h}
+	var conds []metav1.Condition
+
+	s.Fail(&conds, 1, "A", errors.New("boom"))
+	s.Fail(&conds, 2, "A", errors.New("boom again"))
+	s.Pass(&conds, 3, "A", "done")
+	s.Pass(&conds, 3, "B", "done")
+	s.SetReady(&conds, 3, "")
+
+	a := h.Of("A")
+	if len(a) != 2 {
+		t.Fatalf("A has %d entries, want 2 — a failure restated is not a transition: %+v", len(a), a)
+	}
+	if a[0].Status != metav1.ConditionFalse || a[0].Message != "boom" || a[0].ObservedGeneration != 1 {
+		t.Errorf("first transition of A = %+v", a[0])
+	}
+	if a[1].Status != metav1.ConditionTrue || a[1].ObservedGeneration != 3 {
+		t.Errorf("second transition of A = %+v", a[1])
+	}
+	if a[0].LastTransitionTime.IsZero() {
+		t.Error("an entry must say when the transition happened")
+	}
+
+	// B was blocked, then passed: both are transitions of its own.
+	if b := h.Of("B"); len(b) != 2 || b[0].Reason != conditions.ReasonWaitingForDependency {
+		t.Errorf("B = %+v", b)
+	}
+	if r := h.Of(conditions.TypeReady); len(r) != 2 || r[1].Status != metav1.ConditionTrue {
+		t.Errorf("Ready = %+v", r)
+	}
+}
+
+// The mutate function UpdateStatus runs can run once per attempt. Each attempt
+// starts from state read again, so the history a lost attempt appended to is
+// thrown away with it, and the one that wins appends once.
+func TestStagesHistorySurvivesUpdateStatusRetries(t *testing.T) {
+	stored := &widget{ObjectMeta: metav1.ObjectMeta{Name: "w", Generation: 1}}
+	stages := conditions.Stages{Types: []string{"A"}}
+
+	read := func(context.Context) (*widget, error) {
+		return stored.DeepCopyObject().(*widget), nil
+	}
+	attempts := 0
+	write := func(_ context.Context, w *widget) error {
+		attempts++
+		if attempts == 1 {
+			return apierrors.NewConflict(schema.GroupResource{Resource: "widgets"}, w.Name, errors.New("stale"))
+		}
+		stored = w
+		return nil
+	}
+	mutate := func(w *widget) {
+		s := stages
+		s.History = &w.Status.History
+		s.Fail(&w.Status.Conditions, w.Generation, "A", errors.New("boom"))
+	}
+
+	if err := conditions.UpdateStatusVia(context.Background(), read, write, mutate); err != nil {
+		t.Fatalf("UpdateStatusVia: %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected the conflict to be retried, got %d attempts", attempts)
+	}
+	if got := stored.Status.History.Of("A"); len(got) != 1 {
+		t.Errorf("A has %d entries after a retry, want 1: %+v", len(got), got)
+	}
+
+	// A later reconcile restating the same failure adds nothing, and so does
+	// not write at all.
+	attempts = 1
+	if err := conditions.UpdateStatusVia(context.Background(), read, write, mutate); err != nil {
+		t.Fatalf("UpdateStatusVia: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("restating a failure should not write, got %d more attempts", attempts-1)
+	}
+	if got := stored.Status.History.Of("A"); len(got) != 1 {
+		t.Errorf("A has %d entries after a resync, want 1", len(got))
+	}
+}
@@ -0,0 +1,200 @@
+package conditions
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// detailsSeparator sits between the summary of a rendered [Message] and its
+// details.
+const detailsSeparator = " | "
+
+// Message is a condition message with structure: a short summary for a human,
+// and key/value details — the device path, the node — for a human and a tool
+// alike.
+//
+// Stage messages used to be free-form strings, and everything that wanted a
+// field out of one — an alert label, a CLI column — had to regex-match the
+// wording, which broke the first time someone rephrased it. A Message renders
+// as
+//
+//	summary | device=/dev/sdb node="worker 1"
+//
+// which reads fine in `kubectl describe`, and [ParseMessage] gets the fields
+// back out. The zero value is an empty message; build one with [NewMessage]:
+//
+//	msg := conditions.NewMessage("the disk is not empty").
+//		With("device", dev.Path).
+//		With("node", nodeName)
+//	stages.Wait(conds, generation, "DeviceConsumable", "DeviceNotEmpty", msg.String())
+type Message struct {
+	Summary string
+	Details []Detail
+}
+
+// Detail is one key/value pair of a [Message].
+type Detail struct {
+	Key   string
+	Value string
+}
+
+// NewMessage returns a message with summary and no details.
+func NewMessage(summary string) Message {
+	return Message{Summary: summary}
+}
+
+// With returns m with the detail key set to value. A key that is already there
+// keeps its place and takes the new value; a new one goes last.
+//
+// Put the details that matter most first: when the rendered message does not fit
+// [MaxMessageLen], they are dropped from the end.
+//
+// A key is an identifier — letters, digits, '_', '-' and '.'. Anything else in
+// it is replaced with '_' so that the message still parses back.
+func (m Message) With(key, value string) Message {
+	key = detailKey(key)
+
+	details := make([]Detail, len(m.Details), len(m.Details)+1)
+	copy(details, m.Details)
+	m.Details = details
+
+	for i := range m.Details {
+		if m.Details[i].Key == key {
+			m.Details[i].Value = value
+			return m
+		}
+	}
+	m.Details = append(m.Details, Detail{Key: key, Value: value})
+	return m
+}
+
+// Value returns the value of the detail key, and whether m has it.
+func (m Message) Value(key string) (string, bool) {
+	for _, d := range m.Details {
+		if d.Key == key {
+			return d.Value, true
+		}
+	}
+	return "", false
+}
+
+// String renders m within [MaxMessageLen]. The same message always renders the
+// same way, so a condition built from it does not flap between reconciles.
+//
+// When the whole message does not fit, details are dropped from the end until it
+// does, and only a summary that does not fit on its own is cut, the way
+// [TruncateMessage] cuts any message. The summary is what a reader needs first;
+// a detail is only worth having whole.
+//
+// The summary and every detail go through [DefaultRedactor] before they are
+// measured, so that a redaction by the builder the message is handed to cannot
+// push it back over the cap.
+func (m Message) String() string {
+	summary := DefaultRedactor.Redact(m.Summary)
+	n := utf8.RuneCountInString(summary)
+	if n > MaxMessageLen {
+		return TruncateMessage(summary)
+	}
+
+	var b strings.Builder
+	b.WriteString(summary)
+
+	for i, d := range m.Details {
+		sep := " "
+		if i == 0 {
+			sep = detailsSeparator
+		}
+		pair := DefaultRedactor.Redact(sep + d.Key + "=" + detailValue(d.Value))
+
+		n += utf8.RuneCountInString(pair)
+		if n > MaxMessageLen {
+			break
+		}
+		b.WriteString(pair)
+	}
+	return b.String()
+}
+
+// ParseMessage reads back a message rendered by [Message.String]. A message with
+// no details — free-form text included — parses as a summary alone, so it is
+// safe to call on any condition's message.
+//
+// The details are taken to start at the first " | " after which the rest of the
+// message reads as key=value pairs, so a summary that itself contains " | "
+// still parses, unless what follows it happens to look like details too.
+func ParseMessage(s string) Message {
+	for rest, offset := s, 0; ; {
+		i := strings.Index(rest, detailsSeparator)
+		if i < 0 {
+			return Message{Summary: s}
+		}
+		if details, ok := parseDetails(rest[i+len(detailsSeparator):]); ok {
+			return Message{Summary: s[:offset+i], Details: details}
+		}
+		offset += i + len(detailsSeparator)
+		rest = rest[i+len(detailsSeparator):]
+	}
+}
+
+// parseDetails parses a space-separated list of key=value pairs, and reports
+// whether all of s was one.
+func parseDetails(s string) ([]Detail, bool) {
+	var details []Detail
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || detailKey(s[:eq]) != s[:eq] {
+			return nil, false
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			value, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		details = append(details, Detail{Key: key, Value: value})
+
+		if s == "" {
+			break
+		}
+		if s[0] != ' ' || len(s) == 1 {
+			return nil, false
+		}
+		s = s[1:]
+	}
+	return details, len(details) > 0
+}
+
+func detailKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// detailValue quotes a value that would not read back as itself bare.
+func detailValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \"=|") || strings.IndexFunc(v, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return strconv.Quote(v)
+	}
+	return v
+}
@@ -0,0 +1,116 @@
+package conditions_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+func TestMessageString(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		msg  conditions.Message
+		want string
+	}{
+		{
+			name: "a summary alone",
+			msg:  conditions.NewMessage("the disk is not empty"),
+			want: "the disk is not empty",
+		},
+		{
+			name: "details in the order they were added",
+			msg:  conditions.NewMessage("the disk is not empty").With("device", "/dev/sdb").With("node", "worker-1"),
+			want: "the disk is not empty | device=/dev/sdb node=worker-1",
+		},
+		{
+			name: "values that would not read back bare are quoted",
+			msg:  conditions.NewMessage("failed").With("error", `exit status 5: "busy"`).With("empty", ""),
+			want: `failed | error="exit status 5: \"busy\"" empty=""`,
+		},
+		{
+			name: "a key set twice keeps its place",
+			msg:  conditions.NewMessage("s").With("a", "1").With("b", "2").With("a", "3"),
+			want: "s | a=3 b=2",
+		},
+		{
+			name: "a key that is not an identifier is made one",
+			msg:  conditions.NewMessage("s").With("lvm vg", "data"),
+			want: "s | lvm_vg=data",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.msg.String(); got != tc.want {
+				t.Errorf("String() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMessageWithDoesNotAliasTheOriginal(t *testing.T) {
+	base := conditions.NewMessage("s").With("a", "1")
+	_ = base.With("b", "2")
+	_ = base.With("a", "changed")
+
+	if got := base.String(); got != "s | a=1" {
+		t.Errorf("base = %q, want it unchanged", got)
+	}
+}
+
+func TestMessageDropsDetailsBeforeCuttingTheSummary(t *testing.T) {
+	summary := strings.Repeat("s", conditions.MaxMessageLen-20)
+	msg := conditions.NewMessage(summary).
+		With("device", "/dev/sdb").
+		With("stderr", strings.Repeat("x", 100))
+
+	got := msg.String()
+	if got != summary+" | device=/dev/sdb" {
+		t.Errorf("expected the detail that does not fit dropped, and the one before kept, got ...%q", got[len(summary):])
+	}
+
+	long := conditions.NewMessage(strings.Repeat("é", conditions.MaxMessageLen+1)).With("device", "/dev/sdb")
+	if got := long.String(); utf8.RuneCountInString(got) != conditions.MaxMessageLen || strings.Contains(got, "device") {
+		t.Errorf("a summary that does not fit on its own should be cut like any message, got %d runes", utf8.RuneCountInString(got))
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	for _, msg := range []conditions.Message{
+		conditions.NewMessage("the disk is not empty").With("device", "/dev/sdb").With("node", "worker 1"),
+		conditions.NewMessage("quoting").With("q", `a "b" = c | d`).With("empty", "").With("tab", "a\tb"),
+		conditions.NewMessage("a summary | with a bar").With("k", "v"),
+		conditions.NewMessage("no details"),
+	} {
+		t.Run(msg.Summary, func(t *testing.T) {
+			if got := conditions.ParseMessage(msg.String()); !reflect.DeepEqual(got, msg) {
+				t.Errorf("ParseMessage(%q) = %#v, want %#v", msg.String(), got, msg)
+			}
+		})
+	}
+}
+
+func TestParseMessageOfFreeFormText(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"creating the volume group",
+		"lvcreate failed | see the agent logs",
+		"a = b | not details=",
+	} {
+		got := conditions.ParseMessage(s)
+		if got.Summary != s || got.Details != nil {
+			t.Errorf("ParseMessage(%q) = %#v, want the text as the summary", s, got)
+		}
+	}
+}
+
+func TestMessageValue(t *testing.T) {
+	msg := conditions.ParseMessage("the disk is not empty | device=/dev/sdb node=worker-1")
+	if v, ok := msg.Value("node"); !ok || v != "worker-1" {
+		t.Errorf(`Value("node") = %q, %v`, v, ok)
+	}
+	if _, ok := msg.Value("size"); ok {
+		t.Error(`Value("size") should not be found`)
+	}
+}
@@ -0,0 +1,177 @@
+package conditions
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Metrics exports the conditions [Stages] publishes, so that dashboards and
+// alerts over stage conditions are written once rather than as a
+// kube-state-metrics custom resource config per module, each shaped a little
+// differently.
+//
+// It is a prometheus.Collector. A controller creates one per process and
+// registers it with controller-runtime's registry, where the manager's metrics
+// endpoint serves it:
+//
+//	m := conditions.NewMetrics()
+//	metrics.Registry.MustRegister(m)
+//
+// and binds it to the resource on every reconcile, the same way as the
+// [Stages.Recorder]:
+//
+//	s := r.stages
+//	s.Metrics = r.metrics.For("LVMVolumeGroup", obj)
+//
+// Two series are exported:
+//
+//   - sds_condition_status: 1 for the status and reason each condition of each
+//     resource currently holds, labelled kind, namespace, name, type, status and
+//     reason. A condition that moves to another status or reason has its old
+//     series deleted rather than set to 0, so a sum by status counts resources;
+//   - sds_condition_non_true_duration_seconds: how long a condition stayed
+//     not passing — False or Unknown, or True or Unknown for a
+//     [Stages.Negative] stage — before it transitioned, taken from its
+//     LastTransitionTime and [Stages.Now], labelled kind, type and status. It
+//     is observed on the way out, so a stage stuck for good shows up in the
+//     gauge, not here.
+//
+// Stages publish from inside the mutate function [UpdateStatus] runs, which
+// runs again on a conflict, and a reconcile whose write failed redoes the same
+// transition on the next pass. The histogram is kept to one observation per
+// spell regardless: a spell is told apart by its LastTransitionTime, and one
+// already observed is not observed again. What cannot be told is whether a
+// write will succeed, so a transition that is never written at all is still
+// observed, once.
+type Metrics struct {
+	status  *prometheus.GaugeVec
+	nonTrue *prometheus.HistogramVec
+
+	mu sync.Mutex
+	// left holds, per condition, the LastTransitionTime of the last spell
+	// observed leaving.
+	left map[conditionKey]metav1.Time
+}
+
+// conditionKey identifies a condition of one resource.
+type conditionKey struct {
+	kind, namespace, name, conditionType string
+}
+
+// NewMetrics creates an unregistered [Metrics].
+func NewMetrics() *Metrics {
+	return &Metrics{
+		status: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sds_condition_status",
+			Help: "The status and reason each condition of a resource currently holds, as 1.",
+		}, []string{"kind", "namespace", "name", "type", "status", "reason"}),
+		nonTrue: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "sds_condition_non_true_duration_seconds",
+			Help: "How long a condition stayed not passing before it transitioned.",
+			// From a second to a day: a stage waiting on a disk or a node
+			// routinely takes minutes, and one failing takes hours to be looked at.
+			Buckets: prometheus.ExponentialBuckets(1, 4, 9),
+		}, []string{"kind", "type", "status"}),
+		left: map[conditionKey]metav1.Time{},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.status.Describe(ch)
+	m.nonTrue.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.status.Collect(ch)
+	m.nonTrue.Collect(ch)
+}
+
+// For binds the metrics to one resource of kind. kind is taken rather than read
+// off obj because a typed object read through a client carries no TypeMeta.
+func (m *Metrics) For(kind string, obj metav1.Object) *ResourceMetrics {
+	return &ResourceMetrics{
+		metrics:   m,
+		kind:      kind,
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+	}
+}
+
+// Forget deletes every series of the resource. Call it once the resource is
+// gone — the controller that published its conditions is the only one that
+// knows when — or its last conditions stay exported for as long as the process
+// lives.
+func (m *Metrics) Forget(kind string, obj metav1.Object) {
+	m.status.DeletePartialMatch(prometheus.Labels{
+		"kind":      kind,
+		"namespace": obj.GetNamespace(),
+		"name":      obj.GetName(),
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.left {
+		if key.kind == kind && key.namespace == obj.GetNamespace() && key.name == obj.GetName() {
+			delete(m.left, key)
+		}
+	}
+}
+
+// ResourceMetrics is [Metrics] bound to one resource by [Metrics.For].
+type ResourceMetrics struct {
+	metrics   *Metrics
+	kind      string
+	namespace string
+	name      string
+}
+
+// forget deletes the status series of prev's type, the way [Metrics.Forget]
+// does for the whole resource, and records how long prev was not passing.
+func (r *ResourceMetrics) forget(s Stages, prev metav1.Condition) {
+	r.metrics.status.DeletePartialMatch(prometheus.Labels{
+		"kind":      r.kind,
+		"namespace": r.namespace,
+		"name":      r.name,
+		"type":      prev.Type,
+	})
+	r.leave(s, prev)
+}
+
+// observe moves the status series of cur's type from prev to cur, and has
+// leave record prev if cur leaves its status.
+func (r *ResourceMetrics) observe(s Stages, prev *metav1.Condition, cur metav1.Condition) {
+	if prev != nil && (prev.Status != cur.Status || prev.Reason != cur.Reason) {
+		r.metrics.status.DeleteLabelValues(
+			r.kind, r.namespace, r.name, prev.Type, string(prev.Status), prev.Reason)
+	}
+	r.metrics.status.WithLabelValues(
+		r.kind, r.namespace, r.name, cur.Type, string(cur.Status), cur.Reason).Set(1)
+
+	if prev != nil && prev.Status != cur.Status {
+		r.leave(s, *prev)
+	}
+}
+
+// leave records how long prev was not passing, by the polarity s gives it, once
+// per spell — see [Metrics].
+func (r *ResourceMetrics) leave(s Stages, prev metav1.Condition) {
+	if s.passing(&prev) || prev.LastTransitionTime.IsZero() {
+		return
+	}
+
+	key := conditionKey{r.kind, r.namespace, r.name, prev.Type}
+	r.metrics.mu.Lock()
+	last, seen := r.metrics.left[key]
+	r.metrics.left[key] = prev.LastTransitionTime
+	r.metrics.mu.Unlock()
+	if seen && last.Equal(&prev.LastTransitionTime) {
+		return
+	}
+
+	r.metrics.nonTrue.WithLabelValues(r.kind, prev.Type, string(prev.Status)).
+		Observe(s.now().Sub(prev.LastTransitionTime.Time).Seconds())
+}
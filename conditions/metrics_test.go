@@ -0,0 +1,168 @@
+package conditions_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+// nonTrue is sds_condition_non_true_duration_seconds holding one observation,
+// to be formatted with its status, type and seconds. The buckets are laid out
+// for a duration between 256s and 1024s.
+const nonTrue = `
+# HELP sds_condition_non_true_duration_seconds How long a condition stayed not passing before it transitioned.
+# TYPE sds_condition_non_true_duration_seconds histogram
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="1"} 0
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="4"} 0
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="16"} 0
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="64"} 0
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="256"} 0
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="1024"} 1
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="4096"} 1
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="16384"} 1
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="65536"} 1
+sds_condition_non_true_duration_seconds_bucket{kind="Widget",status="%[1]s",type="%[2]s",le="+Inf"} 1
+sds_condition_non_true_duration_seconds_sum{kind="Widget",status="%[1]s",type="%[2]s"} %[3]v
+sds_condition_non_true_duration_seconds_count{kind="Widget",status="%[1]s",type="%[2]s"} 1
+`
+
+func TestMetricsExportTheCurrentStatusOfEachCondition(t *testing.T) {
+	m := conditions.NewMetrics()
+	s := conditions.Stages{Types: []string{"A", "B"}, Metrics: m.For("Widget", newPod())}
+	var conds []metav1.Condition
+
+	s.Fail(&conds, 1, "A", errors.New("boom"))
+	s.Pass(&conds, 2, "A", "done")
+
+	// The series for the failure must be gone, not left at 0: a sum by status
+	// is how a dashboard counts resources, and a stale 0 is one more series to
+	// filter out of every query.
+	const want = `
+# HELP sds_condition_status The status and reason each condition of a resource currently holds, as 1.
+# TYPE sds_condition_status gauge
+sds_condition_status{kind="Widget",name="target",namespace="default",reason="ReconcileFailed",status="False",type="Ready"} 1
+sds_condition_status{kind="Widget",name="target",namespace="default",reason="Reconciled",status="True",type="A"} 1
+sds_condition_status{kind="Widget",name="target",namespace="default",reason="WaitingForDependency",status="False",type="B"} 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "sds_condition_status"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricsObserveTimeSpentNotTrue(t *testing.T) {
+	m := conditions.NewMetrics()
+	s := conditions.Stages{Types: []string{"A", "B"}, Metrics: m.For("Widget", newPod())}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+	var conds []metav1.Condition
+
+	s.Fail(&conds, 1, "A", errors.New("failing"))
+	now = now.Add(10 * time.Minute)
+	s.Fail(&conds, 1, "A", errors.New("still failing"))
+	if n := testutil.CollectAndCount(m, "sds_condition_non_true_duration_seconds"); n != 0 {
+		t.Fatalf("a condition that stays False has not left the state yet, got %d series", n)
+	}
+
+	s.Pass(&conds, 1, "A", "done")
+
+	want := fmt.Sprintf(nonTrue, "False", "A", 600)
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "sds_condition_non_true_duration_seconds"); err != nil {
+		t.Errorf("expected ten minutes False observed: %v", err)
+	}
+}
+
+// For a negative stage False is the healthy state: the time it spends True is
+// what the histogram is for.
+func TestMetricsObserveTimeSpentNotPassingOnANegativeStage(t *testing.T) {
+	m := conditions.NewMetrics()
+	s := conditions.Stages{Types: []string{"A", "B"}, Metrics: m.For("Widget", newPod())}
+	s.Negative = []string{"A"}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+	var conds []metav1.Condition
+
+	s.Pass(&conds, 1, "A", "")
+	now = now.Add(time.Hour)
+	s.Wait(&conds, 1, "A", "ThinPoolOverfilled", "97% used")
+	if n := testutil.CollectAndCount(m, "sds_condition_non_true_duration_seconds"); n != 0 {
+		t.Fatalf("the time A spent passing must not be observed, got %d series", n)
+	}
+
+	now = now.Add(10 * time.Minute)
+	s.Pass(&conds, 1, "A", "")
+
+	want := fmt.Sprintf(nonTrue, "True", "A", 600)
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "sds_condition_non_true_duration_seconds"); err != nil {
+		t.Errorf("expected ten minutes True observed: %v", err)
+	}
+}
+
+func TestMetricsForget(t *testing.T) {
+	m := conditions.NewMetrics()
+	s := conditions.Stages{Types: []string{"A", "B"}, Metrics: m.For("Widget", newPod())}
+	var conds []metav1.Condition
+	s.Pass(&conds, 1, "A", "done")
+
+	m.Forget("Widget", newPod())
+
+	if n := testutil.CollectAndCount(m, "sds_condition_status"); n != 0 {
+		t.Errorf("a forgotten resource must not be exported, got %d series", n)
+	}
+}
+
+// UpdateStatus reruns mutate on a conflict, against state read again that still
+// holds the old status, and a reconcile whose write failed redoes the same
+// transition. Either way the spell A spent False ends once.
+func TestMetricsObserveATransitionOnceAcrossRetries(t *testing.T) {
+	m := conditions.NewMetrics()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stages := conditions.Stages{
+		Types:   []string{"A"},
+		Metrics: m.For("Widget", newPod()),
+		Now:     func() time.Time { return now },
+	}
+	stored := &widget{ObjectMeta: metav1.ObjectMeta{Name: "w", Generation: 1}}
+	stages.Fail(&stored.Status.Conditions, 1, "A", errors.New("boom"))
+	now = now.Add(10 * time.Minute)
+
+	read := func(context.Context) (*widget, error) {
+		return stored.DeepCopyObject().(*widget), nil
+	}
+	var failures []error
+	write := func(_ context.Context, w *widget) error {
+		if len(failures) > 0 {
+			err := failures[0]
+			failures = failures[1:]
+			return err
+		}
+		stored = w
+		return nil
+	}
+	mutate := func(w *widget) {
+		stages.Pass(&w.Status.Conditions, w.Generation, "A", "")
+	}
+
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "widgets"}, "w", errors.New("stale"))
+	failures = []error{conflict, conflict, errors.New("connection refused")}
+	if err := conditions.UpdateStatusVia(context.Background(), read, write, mutate); err == nil {
+		t.Fatal("expected the write to fail after the conflicts")
+	}
+	if err := conditions.UpdateStatusVia(context.Background(), read, write, mutate); err != nil {
+		t.Fatalf("UpdateStatusVia: %v", err)
+	}
+
+	want := fmt.Sprintf(nonTrue, "False", "A", 600)
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want), "sds_condition_non_true_duration_seconds"); err != nil {
+		t.Errorf("expected one observation of ten minutes: %v", err)
+	}
+}
@@ -13,6 +13,14 @@ const (
 	PhaseInProgress = "InProgress"
 	PhaseError      = "Error"
 	PhaseReady      = "Ready"
+	// PhaseTerminating is a resource being torn down — see [Stages.Teardown].
+	PhaseTerminating = "Terminating"
+	// PhaseStalled is a resource with a stage in progress for longer than its
+	// deadline — see [Stages.Deadlines].
+	PhaseStalled = "Stalled"
+	// PhaseReadyWithWarnings is a ready resource with an advisory stage not
+	// passing — see [Stages.Advisory].
+	PhaseReadyWithWarnings = "ReadyWithWarnings"
 )
 
 // Aggregate folds a set of stage conditions into a single status, following the
@@ -59,6 +67,9 @@ func AggregateReady(conds []metav1.Condition, generation int64, stages ...string
 //   - PhaseInProgress some stage is False for a non-terminal reason, such as
 //     waiting on a dependency;
 //   - PhaseReady      every stage is True.
+//
+// PhaseTerminating comes from [Stages.Phase], which also reads the aggregate, and
+// PhaseStalled from a Stages with deadlines.
 func DerivePhase(conds []metav1.Condition, stages ...string) string {
 	return Stages{Types: stages}.Phase(conds)
 }
@@ -0,0 +1,178 @@
+package conditions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+)
+
+// Format is an output format of [Stages.Render].
+type Format string
+
+const (
+	// FormatTable is an aligned text table, the way kubectl prints resources.
+	FormatTable Format = "table"
+	// FormatJSON is the [Report] as indented JSON.
+	FormatJSON Format = "json"
+	// FormatYAML is the [Report] as YAML.
+	FormatYAML Format = "yaml"
+)
+
+// Report is what [Stages.Render] prints: the phase of a resource and a row per
+// condition.
+type Report struct {
+	Phase      string `json:"phase"`
+	Generation int64  `json:"generation"`
+	Conditions []Row  `json:"conditions"`
+}
+
+// Row is one condition of a [Report].
+type Row struct {
+	// Order is the position of the stage in Types, counting from one, and zero
+	// for a condition that is not a stage — the aggregate, or a signal
+	// condition published on its own schedule.
+	Order              int                    `json:"order,omitempty"`
+	Type               string                 `json:"type"`
+	Status             metav1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime"`
+	// Age is how long ago LastTransitionTime was, as kubectl prints it.
+	Age                string `json:"age"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+	// Stale is set on a condition recorded for an older generation than the
+	// one the report is for.
+	Stale bool `json:"stale,omitempty"`
+	// Blocking is set on a stage that the resource is stuck on, as
+	// [Stages.ReadyCondition] names it.
+	Blocking bool `json:"blocking,omitempty"`
+}
+
+// Report builds the [Report] of conds for the reconcile of generation: the
+// stages in Types order, then the aggregates, then every other condition in the
+// order conds has them. Ages are measured against [Stages.Now].
+func (s Stages) Report(conds []metav1.Condition, generation int64) Report {
+	now := s.now()
+
+	blocking := make(map[string]bool)
+	if s.AggregateAt(conds, generation) != metav1.ConditionTrue {
+		for _, t := range s.stuckRoots(conds, generation) {
+			blocking[t] = true
+		}
+	}
+
+	report := Report{Phase: s.PhaseAt(conds, generation), Generation: generation}
+	row := func(order int, c metav1.Condition) {
+		age := "<unknown>"
+		if !c.LastTransitionTime.IsZero() {
+			age = duration.HumanDuration(now.Sub(c.LastTransitionTime.Time))
+		}
+		report.Conditions = append(report.Conditions, Row{
+			Order:              order,
+			Type:               c.Type,
+			Status:             c.Status,
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+			Age:                age,
+			ObservedGeneration: c.ObservedGeneration,
+			Stale:              c.ObservedGeneration < generation,
+			Blocking:           blocking[c.Type],
+		})
+	}
+
+	listed := make(map[string]bool, len(s.Types)+1)
+	for i, t := range s.Types {
+		listed[t] = true
+		if c := Get(conds, t); c != nil {
+			row(i+1, *c)
+		} else if blocking[t] {
+			// A stage the resource is stuck on because it never ran is worth a
+			// line of its own, or the highlight would point at nothing.
+			row(i+1, metav1.Condition{Type: t, Status: metav1.ConditionUnknown, ObservedGeneration: generation})
+		}
+	}
+	aggregates := []string{s.readyType()}
+	if len(s.Advisory) > 0 {
+		aggregates = append(aggregates, s.healthyType())
+	}
+	for _, t := range aggregates {
+		listed[t] = true
+		if c := Get(conds, t); c != nil {
+			row(0, *c)
+		}
+	}
+	for _, c := range conds {
+		if !listed[c.Type] {
+			row(0, c)
+		}
+	}
+	return report
+}
+
+// Render writes the [Report] of conds for the reconcile of generation to w, in
+// format.
+//
+// It is for the CLIs and debug endpoints that had each grown a loop printing
+// conditions. The table reads like `kubectl get`:
+//
+//	   #  TYPE       STATUS  REASON                AGE  STALE  MESSAGE
+//	   1  NodeReady  True    Reconciled            3d   yes    node is labelled
+//	>  2  VGCreated  False   ReconcileFailed       5m          vgcreate: device busy
+//	   3  ThinPool   False   WaitingForDependency  5m          waiting for VGCreated
+//	      Ready      False   ReconcileFailed       5m          VGCreated: vgcreate: device busy
+//
+// with the stages the resource is stuck on marked, and STALE reading "yes" on a
+// condition recorded for an older generation. The JSON and YAML formats are the
+// Report itself, for machines.
+func (s Stages) Render(w io.Writer, conds []metav1.Condition, generation int64, format Format) error {
+	report := s.Report(conds, generation)
+
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case FormatYAML:
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case FormatTable, "":
+		return renderTable(w, report)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func renderTable(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\t#\tTYPE\tSTATUS\tREASON\tAGE\tSTALE\tMESSAGE")
+	for _, r := range report.Conditions {
+		mark, order, stale := "", "", ""
+		if r.Blocking {
+			mark = ">"
+		}
+		if r.Order > 0 {
+			order = strconv.Itoa(r.Order)
+		}
+		if r.Stale {
+			stale = "yes"
+		}
+		// One line per condition, whatever the message holds.
+		msg := strings.Join(strings.Fields(r.Message), " ")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", mark, order, r.Type, r.Status, r.Reason, r.Age, stale, msg)
+	}
+	return tw.Flush()
+}
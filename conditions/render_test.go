@@ -0,0 +1,121 @@
+package conditions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/sds-common-lib/conditions"
+)
+
+// A resource at generation 2 whose second stage failed five minutes ago, with a
+// first stage last recorded for generation 1.
+func TestRender(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	s := conditions.Stages{
+		Types: []string{"NodeReady", "VGCreated", "ThinPool"},
+		Now:   func() time.Time { return now },
+	}
+
+	var conds []metav1.Condition
+	s.Pass(&conds, 1, "NodeReady", "node is labelled")
+	now = start.Add(3*24*time.Hour - 5*time.Minute)
+	s.Fail(&conds, 2, "VGCreated", errors.New("vgcreate: device busy\n(retrying)"))
+	conds = append(conds, metav1.Condition{
+		Type:               "Signal",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Observed",
+		ObservedGeneration: 2,
+		LastTransitionTime: metav1.NewTime(now),
+	})
+	now = start.Add(3 * 24 * time.Hour)
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := s.Render(&buf, conds, 2, conditions.FormatTable); err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+
+		want := strings.Join([]string{
+			"   #  TYPE       STATUS  REASON                AGE  STALE  MESSAGE",
+			"   1  NodeReady  True    Reconciled            3d   yes    node is labelled",
+			">  2  VGCreated  False   ReconcileFailed       5m          vgcreate: device busy (retrying)",
+			"   3  ThinPool   False   WaitingForDependency  5m          waiting for VGCreated",
+			"      Ready      False   ReconcileFailed       5m          VGCreated: vgcreate: device busy (retrying)",
+			"      Signal     True    Observed              5m",
+			"",
+		}, "\n")
+		if got := trimLines(buf.String()); got != want {
+			t.Errorf("Render\n got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	want := s.Report(conds, 2)
+	for _, tc := range []struct {
+		format    conditions.Format
+		unmarshal func([]byte, any) error
+	}{
+		{conditions.FormatJSON, json.Unmarshal},
+		{conditions.FormatYAML, func(data []byte, v any) error { return yaml.Unmarshal(data, v) }},
+	} {
+		t.Run(string(tc.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := s.Render(&buf, conds, 2, tc.format); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+
+			var got conditions.Report
+			if err := tc.unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("the output does not read back: %v\n%s", err, buf.String())
+			}
+			if got.Phase != conditions.PhaseError || len(got.Conditions) != len(want.Conditions) {
+				t.Fatalf("Report = %+v", got)
+			}
+			for i := range got.Conditions {
+				g, w := got.Conditions[i], want.Conditions[i]
+				if g.Type != w.Type || g.Blocking != w.Blocking || g.Stale != w.Stale ||
+					g.Age != w.Age || !g.LastTransitionTime.Equal(&w.LastTransitionTime) {
+					t.Errorf("row %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+
+	t.Run("an unknown format", func(t *testing.T) {
+		if err := s.Render(&bytes.Buffer{}, conds, 2, "xml"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+// trimLines drops the padding tabwriter leaves after the last column of a row
+// whose last cell is empty.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRenderMarksAStageThatNeverRan(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A", "B"}}
+	var conds []metav1.Condition
+	s.Pass(&conds, 1, "A", "")
+
+	report := s.Report(conds, 1)
+	last := report.Conditions[len(report.Conditions)-1]
+	if last.Type != "B" || !last.Blocking || last.Order != 2 || last.Status != metav1.ConditionUnknown {
+		t.Errorf("the missing stage should be listed as blocking, got %+v", report.Conditions)
+	}
+	if report.Phase != conditions.PhasePending {
+		t.Errorf("Phase = %q, want %q", report.Phase, conditions.PhasePending)
+	}
+}
@@ -3,7 +3,10 @@ package conditions
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,9 +26,55 @@ import (
 // rather than rewriting the machine around it.
 type Stages struct {
 	// Types are the stage condition types, in the order the reconcile walks
-	// them. The order is what Gate uses to decide what is downstream.
+	// them. Unless Requires says otherwise, the order is also what Gate uses to
+	// decide what is downstream: every stage requires the one before it.
 	Types []string
 
+	// Requires declares, per stage, the stages it cannot proceed without. With
+	// it set, Types stops being a chain and becomes a graph: Gate blocks only
+	// what transitively requires the stage that did not pass, and the aggregate
+	// names every stage that is stuck on its own account rather than just the
+	// first one in Types.
+	//
+	// It is for reconciles with independent branches — preparing a node and
+	// creating a volume group, both feeding a final stage — where a chain
+	// would report the second branch as blocked by a failure in the first that
+	// it has nothing to do with.
+	//
+	// A stage missing from the map requires nothing. Types must still list
+	// every stage after the ones it requires, which [Stages.Validate] checks;
+	// that is what keeps a cycle from being expressible at all.
+	Requires map[string][]string
+
+	// Negative lists the stages of negative polarity: conditions such as
+	// Degraded or DiskPressure, where True is the bad state and False the
+	// good one. Everything here reads them inversely — a negative stage passes
+	// when it is False — and the methods that record a stage write them
+	// inversely, so that [Stages.Pass] on DiskPressure records it False.
+	//
+	// It is so that a condition can keep the name it naturally has, rather than
+	// the awkward positive one — NoDiskPressure — a machine that only knew True
+	// as healthy used to force on it. Unknown means the same for both.
+	Negative []string
+
+	// Advisory lists the stages that warn rather than block: a thin pool above
+	// 80%, a node with a single path to its disks. They are walked and recorded
+	// like any other, but do not count towards the aggregate — a resource with
+	// an advisory stage not passing is still ready — and nothing is blocked
+	// behind them.
+	//
+	// What they say goes into a second aggregate instead, [Stages.HealthyType],
+	// and a resource that is ready with one of them not passing is in
+	// [PhaseReadyWithWarnings]. Without this a check like that either held
+	// Ready False on a perfectly usable volume or could not be a stage at all.
+	//
+	// No stage may require an advisory one.
+	Advisory []string
+
+	// HealthyType is the aggregate of the advisory stages, published alongside
+	// [Stages.ReadyType] when there are any. Defaults to [TypeHealthy].
+	HealthyType string
+
 	// ReadyType is the aggregate condition gated when a stage does not pass.
 	// Defaults to [TypeReady].
 	ReadyType string
@@ -43,6 +92,13 @@ type Stages struct {
 	// Blocked is the reason recorded on the stages downstream of one that did
 	// not pass, and on the aggregate. Defaults to [ReasonWaitingForDependency].
 	Blocked string
+	// Deleting is the reason recorded on a stage being torn down, and on the
+	// aggregate for as long as [Stages.Teardown] runs. Defaults to
+	// [ReasonDeleting].
+	Deleting string
+	// Stalled is the reason recorded on the aggregate when a stage has been in
+	// progress for longer than its deadline. Defaults to [ReasonStalled].
+	Stalled string
 
 	// SkipMissing treats a stage that has no condition as carrying no evidence
 	// either way, instead of as Unknown.
@@ -61,6 +117,84 @@ type Stages struct {
 	// Unknown either way: it says the controller looked and could not tell,
 	// which is evidence, unlike the absence of a condition.
 	SkipMissing bool
+
+	// RequireCurrentGeneration reads a True stage recorded for an older
+	// generation than the one being reconciled as still in progress, rather
+	// than as passed.
+	//
+	// Without it a stage that was True for generation 3 still counts as True
+	// once the spec has moved to generation 4, and the aggregate reports the
+	// resource ready for a spec the controller has not looked at yet — the gap
+	// [IsStale] describes. With it [Stages.ReadyCondition] reports "X has not
+	// observed generation N" instead, and [Stages.AggregateAt] and
+	// [Stages.PhaseAt] read the stage as if it were waiting. [Stages.Aggregate]
+	// and [Stages.Phase], which are not told the generation, measure against
+	// the newest one the conditions were recorded for.
+	//
+	// It covers a passing stage only — True, or False for a [Stages.Negative]
+	// one. A stage that is not passing or is Unknown is not passing for either
+	// generation, and what it says about the older one is still the most useful
+	// thing to show.
+	RequireCurrentGeneration bool
+
+	// Deadlines bounds, per stage, how long it may stay in progress — not
+	// passing, with any reason other than [Stages.Failed] and [Stages.Deleting],
+	// while every stage it requires passes — before it counts as stalled.
+	// Whether a stage is held back is read off the requirement graph, not its
+	// reason: one that [Stages.Await] left waiting on an outside dependency
+	// carries the [Stages.Blocked] reason, and stalls all the same.
+	//
+	// A stage that sits in [Stages.Wait] forever otherwise looks exactly like
+	// one that began waiting a second ago. Past its deadline, measured from its
+	// LastTransitionTime, [Stages.ReadyCondition] gives the aggregate the
+	// [Stages.Stalled] reason and says for how long the stage has been waiting,
+	// and [Stages.Phase] reads [PhaseStalled]. The stage condition itself is
+	// left as it is: it still says what the stage is waiting for.
+	//
+	// LastTransitionTime moves only when the status does, so a stage that goes
+	// from failing to waiting keeps counting from when it first went False.
+	// Nothing re-evaluates a deadline by itself; requeue for
+	// [Stages.RequeueAfter] so that a reconcile sees it pass.
+	//
+	// A deadline on an [Stages.Advisory] stage is ignored: it never holds the
+	// aggregate back, so there is nothing for it to stall.
+	Deadlines map[string]time.Duration
+
+	// Now is the clock Deadlines are measured against, and the one that stamps
+	// the LastTransitionTime of a condition published here. Defaults to
+	// time.Now.
+	Now func() time.Time
+
+	// Recorder, when set, is told about every stage condition that
+	// [Stages.Pass], [Stages.Fail] and [Stages.Wait] flip, and about every flip
+	// of the aggregate. A condition holds only its latest state, so this is
+	// where a history of transitions comes from — see [EventsFor].
+	//
+	// Only transitions are recorded: a resync that restates a status says
+	// nothing new, and an Event per reconcile would drown the ones that matter.
+	// The stages Gate blocks downstream are not recorded either; the stage that
+	// blocked them already was, and says why.
+	Recorder Recorder
+
+	// History, when set, gets every transition of a stage condition or of the
+	// aggregate that a method here makes, the blocked stages included: unlike
+	// an Event it is kept per condition type, so a stage that is blocked often
+	// does not crowd out one that is not. Bind it to the resource the same way
+	// as Recorder:
+	//
+	//	s.History = &obj.Status.ConditionHistory
+	History *History
+	// HistoryLimit is how many transitions History keeps per condition type.
+	// Defaults to [DefaultHistoryLimit].
+	HistoryLimit int
+
+	// Metrics, when set, exports every condition a method here writes — see
+	// [Metrics.For].
+	Metrics *ResourceMetrics
+
+	// Redactor strips secrets out of every message a method here writes.
+	// Defaults to [DefaultRedactor].
+	Redactor *Redactor
 }
 
 // Validate reports what is wrong with the stage set, or nil when it is usable.
@@ -91,6 +225,53 @@ func (s Stages) Validate() error {
 	if _, clash := seen[s.readyType()]; clash {
 		return fmt.Errorf("the aggregate type %q is also a stage", s.readyType())
 	}
+
+	for _, stage := range s.Negative {
+		if _, ok := seen[stage]; !ok {
+			return fmt.Errorf("%q is declared negative, and is not a stage", stage)
+		}
+	}
+
+	for _, stage := range s.Advisory {
+		if _, ok := seen[stage]; !ok {
+			return fmt.Errorf("%q is declared advisory, and is not a stage", stage)
+		}
+	}
+	if len(s.Advisory) > 0 {
+		if _, clash := seen[s.healthyType()]; clash || s.healthyType() == s.readyType() {
+			return fmt.Errorf("the advisory aggregate type %q is also a stage or the aggregate", s.healthyType())
+		}
+	}
+
+	for stage := range s.Requires {
+		if _, ok := seen[stage]; !ok {
+			return fmt.Errorf("requirements are declared for %q, which is not a stage", stage)
+		}
+	}
+	for stage, d := range s.Deadlines {
+		if _, ok := seen[stage]; !ok {
+			return fmt.Errorf("a deadline is declared for %q, which is not a stage", stage)
+		}
+		if d <= 0 {
+			return fmt.Errorf("the deadline of stage %q is not positive", stage)
+		}
+	}
+
+	listed := make(map[string]struct{}, len(s.Types))
+	for _, t := range s.Types {
+		for _, req := range s.Requires[t] {
+			if _, ok := seen[req]; !ok {
+				return fmt.Errorf("stage %q requires %q, which is not a stage", t, req)
+			}
+			if _, ok := listed[req]; !ok {
+				return fmt.Errorf("stage %q requires %q, which is not listed before it", t, req)
+			}
+			if s.advisory(req) {
+				return fmt.Errorf("stage %q requires %q, which is advisory", t, req)
+			}
+		}
+		listed[t] = struct{}{}
+	}
 	return nil
 }
 
@@ -107,6 +288,13 @@ func (s Stages) Known(stage string) bool {
 	return false
 }
 
+func (s Stages) healthyType() string {
+	if s.HealthyType == "" {
+		return TypeHealthy
+	}
+	return s.HealthyType
+}
+
 func (s Stages) readyType() string {
 	if s.ReadyType == "" {
 		return TypeReady
@@ -142,26 +330,74 @@ func (s Stages) blocked() string {
 	return s.Blocked
 }
 
+func (s Stages) stalledReason() string {
+	if s.Stalled == "" {
+		return ReasonStalled
+	}
+	return s.Stalled
+}
+
+func (s Stages) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+func (s Stages) deleting() string {
+	if s.Deleting == "" {
+		return ReasonDeleting
+	}
+	return s.Deleting
+}
+
 // Aggregate folds the stage conditions into a single status:
 //
 //   - Unknown if a stage is missing or Unknown — the controller has not reached
 //     a verdict on every stage yet;
-//   - False if every stage is known and at least one is False;
-//   - True if every stage is True.
+//   - False if every stage is known and at least one is not passing;
+//   - True if every stage is passing: True, or False for a [Stages.Negative]
+//     one.
 //
 // With no stages the answer is Unknown: an empty set of evidence says nothing,
 // and reporting True would be actively misleading.
 //
 // See [Stages.SkipMissing] for how a missing stage is counted.
+//
+// With [Stages.RequireCurrentGeneration] set it is [Stages.AggregateAt] for the
+// newest generation any stage or the aggregate was recorded for: a stage left
+// behind by a reconcile that got further on another does not pass. A caller
+// that knows the generation it is reconciling should use AggregateAt.
 func (s Stages) Aggregate(conds []metav1.Condition) metav1.ConditionStatus {
-	if len(s.Types) == 0 {
+	if s.RequireCurrentGeneration {
+		return s.AggregateAt(conds, s.observedGeneration(conds))
+	}
+	return s.aggregate(conds)
+}
+
+// observedGeneration is the newest generation a stage or the aggregate was
+// recorded for: the generation the conditions claim to describe.
+func (s Stages) observedGeneration(conds []metav1.Condition) int64 {
+	var generation int64
+	for _, t := range append(s.required(), s.readyType()) {
+		if c := Get(conds, t); c != nil {
+			generation = max(generation, c.ObservedGeneration)
+		}
+	}
+	return generation
+}
+
+// aggregate is [Stages.Aggregate] without regard to generations.
+func (s Stages) aggregate(conds []metav1.Condition) metav1.ConditionStatus {
+	required := s.required()
+	if len(required) == 0 {
 		return metav1.ConditionUnknown
 	}
 
 	result := metav1.ConditionTrue
 	evidence := false
 
-	for _, t := range s.Types {
+	for _, t := range required {
 		c := Get(conds, t)
 		if c == nil {
 			if s.SkipMissing {
@@ -174,7 +410,7 @@ func (s Stages) Aggregate(conds []metav1.Condition) metav1.ConditionStatus {
 		}
 
 		evidence = true
-		if c.Status == metav1.ConditionFalse {
+		if s.notPassing(c) {
 			result = metav1.ConditionFalse
 		}
 	}
@@ -185,9 +421,102 @@ func (s Stages) Aggregate(conds []metav1.Condition) metav1.ConditionStatus {
 	return result
 }
 
+// AggregateAt is [Stages.Aggregate] for the reconcile of generation: with
+// [Stages.RequireCurrentGeneration] set, an aggregate that would be True is False
+// while some stage was last recorded for an older generation. Without it, or
+// with a generation of zero, it is Aggregate.
+func (s Stages) AggregateAt(conds []metav1.Condition, generation int64) metav1.ConditionStatus {
+	status := s.aggregate(conds)
+	if status == metav1.ConditionTrue && s.anyStale(conds, generation) {
+		return metav1.ConditionFalse
+	}
+	return status
+}
+
+// stale reports whether c is a passing stage that, with
+// RequireCurrentGeneration set, counts as not having observed generation.
+func (s Stages) stale(c *metav1.Condition, generation int64) bool {
+	return s.RequireCurrentGeneration &&
+		s.passing(c) &&
+		c.ObservedGeneration < generation
+}
+
+func (s Stages) anyStale(conds []metav1.Condition, generation int64) bool {
+	for _, t := range s.required() {
+		if s.stale(Get(conds, t), generation) {
+			return true
+		}
+	}
+	return false
+}
+
+// waiting reports whether c is a stage in progress: not passing, for a reason
+// that is not a failure or a teardown, and not held back by a stage it
+// requires. What holds it back is read off the graph rather than the reason:
+// [Stages.Await] waits with the same reason [Stages.Gate] blocks with, and a
+// stage stuck on an outside dependency is exactly the one to stall.
+func (s Stages) waiting(conds []metav1.Condition, c *metav1.Condition) bool {
+	if !s.notPassing(c) || c.Reason == s.failed() || c.Reason == s.deleting() {
+		return false
+	}
+	for _, req := range s.prerequisites(c.Type) {
+		if !s.Passing(conds, req) {
+			return false
+		}
+	}
+	return true
+}
+
+// deadline returns when c, a stage in progress with a deadline, stalls.
+func (s Stages) deadline(conds []metav1.Condition, c *metav1.Condition) (time.Time, bool) {
+	d, ok := s.Deadlines[c.Type]
+	if !ok || !s.waiting(conds, c) || c.LastTransitionTime.IsZero() {
+		return time.Time{}, false
+	}
+	return c.LastTransitionTime.Add(d), true
+}
+
+// stalled reports whether c is a stage in progress past its deadline.
+func (s Stages) stalled(conds []metav1.Condition, c *metav1.Condition) bool {
+	if c == nil {
+		return false
+	}
+	at, ok := s.deadline(conds, c)
+	return ok && !s.now().Before(at)
+}
+
+// RequeueAfter returns how long until the next stage in progress reaches its
+// deadline, or zero when none is heading for one. It is the RequeueAfter of
+// the reconcile's result: the stage stalling changes nothing a watch would
+// notice, so the reconcile that reports it has to be scheduled.
+//
+//	return ctrl.Result{RequeueAfter: s.RequeueAfter(obj.Status.Conditions)}, nil
+func (s Stages) RequeueAfter(conds []metav1.Condition) time.Duration {
+	now := s.now()
+
+	var next time.Duration
+	for _, t := range s.required() {
+		c := Get(conds, t)
+		if c == nil {
+			continue
+		}
+		at, ok := s.deadline(conds, c)
+		if !ok || !at.After(now) {
+			continue
+		}
+		if left := at.Sub(now); next == 0 || left < next {
+			next = left
+		}
+	}
+	return next
+}
+
 // ReadyCondition builds the aggregate condition from the stage conditions. The
-// message names the first stage that is not True, which is what makes
-// `kubectl describe` immediately useful on a resource stuck mid-way.
+// message names the first stage that is not passing, which is what makes
+// `kubectl describe` immediately useful on a resource stuck mid-way. With
+// [Stages.Requires] set it names every stage that is not passing while everything
+// it requires is — each branch of the graph that is stuck, and only the stage
+// it is stuck on.
 //
 // A True aggregate carries no message: this builds a condition, it does not know
 // what the pass achieved. Use [Stages.SetReady] to write one that says.
@@ -198,7 +527,7 @@ func (s Stages) Aggregate(conds []metav1.Condition) metav1.ConditionStatus {
 func (s Stages) ReadyCondition(conds []metav1.Condition, generation int64) metav1.Condition {
 	cond := metav1.Condition{
 		Type:               s.readyType(),
-		Status:             s.Aggregate(conds),
+		Status:             s.AggregateAt(conds, generation),
 		Reason:             s.passed(),
 		ObservedGeneration: generation,
 	}
@@ -207,38 +536,220 @@ func (s Stages) ReadyCondition(conds []metav1.Condition, generation int64) metav
 		return cond
 	}
 
-	for _, t := range s.Types {
+	roots := s.stuckRoots(conds, generation)
+	if len(roots) == 0 {
+		// Reached when every stage is missing and SkipMissing is set, or if
+		// Aggregate and stuckRoots ever disagree about what "not passing" means.
+		// Either way the aggregate is not True, so it must not keep the passed
+		// reason.
+		cond.Reason = s.inProgress()
+		return cond
+	}
+
+	var falseRoot, failedRoot, stalledRoot bool
+	msgs := make([]string, 0, len(roots))
+	for _, t := range roots {
 		c := Get(conds, t)
-		if c != nil && c.Status == metav1.ConditionTrue {
-			continue
+		msg := "waiting for " + t
+		switch {
+		case s.stale(c, generation):
+			msg = fmt.Sprintf("%s has not observed generation %d", t, generation)
+		case s.stalled(conds, c):
+			msg = fmt.Sprintf("%s has been in progress for longer than %s", t, s.Deadlines[t])
+			if c.Message != "" {
+				msg += ": " + c.Message
+			}
+			stalledRoot = true
+		case c != nil && c.Message != "":
+			msg = t + ": " + c.Message
+		}
+		if s.notPassing(c) {
+			falseRoot = true
+			failedRoot = failedRoot || c.Reason == s.failed()
 		}
-		if c == nil && s.SkipMissing {
+		msgs = append(msgs, msg)
+	}
+
+	switch {
+	case stalledRoot && !failedRoot:
+		cond.Reason = s.stalledReason()
+	case falseRoot:
+		cond.Reason = s.failed()
+	default:
+		cond.Reason = s.inProgress()
+	}
+	cond.Message = s.sanitize(strings.Join(msgs, "; "))
+	return cond
+}
+
+// stuckRoots returns, in Types order, the stages that are not passing while no
+// stage they transitively require is stuck as well. On a chain that is the
+// first stage that is not passing. A stale stage counts as not passing.
+func (s Stages) stuckRoots(conds []metav1.Condition, generation int64) []string {
+	// Filled in Types order, which Validate guarantees lists a stage after the
+	// ones it requires, so one pass sees every requirement settled.
+	stuckAbove := make(map[string]bool, len(s.Types))
+	var roots []string
+
+	for _, t := range s.required() {
+		above := false
+		for _, req := range s.prerequisites(t) {
+			if stuckAbove[req] {
+				above = true
+				break
+			}
+		}
+
+		c := Get(conds, t)
+		stuck := c == nil && !s.SkipMissing ||
+			c != nil && !s.passing(c) ||
+			s.stale(c, generation)
+		if stuck && !above {
+			roots = append(roots, t)
+		}
+		stuckAbove[t] = stuck || above
+	}
+	return roots
+}
+
+// Runnable reports whether every stage that stage requires is passing, which is
+// when a reconcile walking a graph of stages can go on to it. On a chain that
+// is the stage before it; the first stage is always runnable.
+//
+// It is what lets a reconcile keep going past a stage that did not pass: with
+// [Stages.Requires] set, the branches that do not depend on it still have work
+// to do, and checking this before each stage replaces the early return that a
+// chain uses.
+func (s Stages) Runnable(conds []metav1.Condition, stage string) bool {
+	for _, req := range s.prerequisites(stage) {
+		if !s.Passing(conds, req) {
+			return false
+		}
+	}
+	return true
+}
+
+// Passing reports whether stage has a verdict and it is the good one: True, or
+// False for a [Stages.Negative] stage.
+func (s Stages) Passing(conds []metav1.Condition, stage string) bool {
+	return s.passing(Get(conds, stage))
+}
+
+func (s Stages) negative(stage string) bool {
+	for _, t := range s.Negative {
+		if t == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// polar turns status, said of a stage as if it were of positive polarity, into
+// what is recorded for stage.
+func (s Stages) polar(stage string, status metav1.ConditionStatus) metav1.ConditionStatus {
+	if !s.negative(stage) {
+		return status
+	}
+	switch status {
+	case metav1.ConditionTrue:
+		return metav1.ConditionFalse
+	case metav1.ConditionFalse:
+		return metav1.ConditionTrue
+	}
+	return status
+}
+
+func (s Stages) passing(c *metav1.Condition) bool {
+	return c != nil && c.Status == s.polar(c.Type, metav1.ConditionTrue)
+}
+
+func (s Stages) notPassing(c *metav1.Condition) bool {
+	return c != nil && c.Status == s.polar(c.Type, metav1.ConditionFalse)
+}
+
+// prerequisites returns the stages stage requires: those in Requires when it is
+// set, the one before it in Types otherwise.
+func (s Stages) prerequisites(stage string) []string {
+	if s.Requires != nil {
+		return s.Requires[stage]
+	}
+	for i, t := range s.Types {
+		if t != stage {
 			continue
 		}
+		for j := i - 1; j >= 0; j-- {
+			if !s.advisory(s.Types[j]) {
+				return s.Types[j : j+1]
+			}
+		}
+		return nil
+	}
+	return nil
+}
 
-		cond.Message = "waiting for " + t
-		switch {
-		case c == nil:
-			cond.Reason = s.inProgress()
-		case c.Status == metav1.ConditionUnknown:
-			cond.Reason = s.inProgress()
-			if c.Message != "" {
-				cond.Message = t + ": " + c.Message
+func (s Stages) advisory(stage string) bool {
+	for _, t := range s.Advisory {
+		if t == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// required returns the stages that count towards the aggregate: Types without
+// the Advisory ones.
+func (s Stages) required() []string {
+	if len(s.Advisory) == 0 {
+		return s.Types
+	}
+	required := make([]string, 0, len(s.Types))
+	for _, t := range s.Types {
+		if !s.advisory(t) {
+			required = append(required, t)
+		}
+	}
+	return required
+}
+
+// HealthyCondition builds the aggregate of the [Stages.Advisory] stages: True
+// when every one of them passes, False when one does not — with the reason of
+// the first such stage and the message of every one — and Unknown while one has
+// no verdict. It is published by [Stages.SetReady], and by [Stages.Fail] and
+// [Stages.Wait] on an advisory stage.
+func (s Stages) HealthyCondition(conds []metav1.Condition, generation int64) metav1.Condition {
+	adv := s
+	adv.Types = s.Advisory
+	adv.Advisory = nil
+	adv.Requires = nil
+
+	cond := metav1.Condition{
+		Type:               s.healthyType(),
+		Status:             adv.aggregate(conds),
+		Reason:             s.passed(),
+		ObservedGeneration: generation,
+	}
+
+	switch cond.Status {
+	case metav1.ConditionUnknown:
+		cond.Reason = s.inProgress()
+	case metav1.ConditionFalse:
+		var msgs []string
+		for _, t := range s.Advisory {
+			c := Get(conds, t)
+			if !s.notPassing(c) {
+				continue
 			}
-		default:
-			cond.Reason = s.failed()
+			if len(msgs) == 0 {
+				cond.Reason = c.Reason
+			}
+			msg := t
 			if c.Message != "" {
-				cond.Message = t + ": " + c.Message
+				msg += ": " + c.Message
 			}
+			msgs = append(msgs, msg)
 		}
-		cond.Message = TruncateMessage(cond.Message)
-		return cond
+		cond.Message = s.sanitize(strings.Join(msgs, "; "))
 	}
-
-	// Reached when every stage is missing and SkipMissing is set, or if
-	// Aggregate and this loop ever disagree about what "not True" means. Either
-	// way the aggregate is not True, so it must not keep the passed reason.
-	cond.Reason = s.inProgress()
 	return cond
 }
 
@@ -257,14 +768,20 @@ func (s Stages) ReadyCondition(conds []metav1.Condition, generation int64) metav
 // is used only when the aggregate is True; below that the message naming the
 // stage that is not passing is the one an operator needs, and msg is dropped.
 //
-// It must not carry secrets: conditions are readable by anyone who can get the
-// resource. The message is truncated to [MaxMessageLen].
+// It should not carry secrets: conditions are readable by anyone who can get
+// the resource. The message goes through [Stages.Redactor] and is truncated to
+// [MaxMessageLen].
 func (s Stages) SetReady(conds *[]metav1.Condition, generation int64, msg string) bool {
 	cond := s.ReadyCondition(*conds, generation)
 	if cond.Status == metav1.ConditionTrue {
-		cond.Message = TruncateMessage(msg)
+		cond.Message = s.sanitize(msg)
 	}
-	return Set(conds, cond)
+	changed := s.publish(conds, cond, true)
+
+	if len(s.Advisory) > 0 && s.publish(conds, s.HealthyCondition(*conds, generation), true) {
+		changed = true
+	}
+	return changed
 }
 
 // Phase computes the coarse phase from the stage conditions, using the
@@ -275,25 +792,65 @@ func (s Stages) SetReady(conds *[]metav1.Condition, generation int64, msg string
 // can at worst make the phase less specific — it cannot strand the resource in
 // an intermediate phase forever.
 //
-//   - PhasePending    no stage has a verdict yet;
-//   - PhaseError      some stage failed, with the [Stages.Failed] reason;
-//   - PhaseInProgress some stage is False for another reason, such as waiting
-//     on a dependency;
-//   - PhaseReady      every stage is True.
+//   - PhasePending           no stage has a verdict yet;
+//   - PhaseError             some stage failed, with the [Stages.Failed] reason;
+//   - PhaseStalled           no stage failed, and some stage has been in
+//     progress for longer than its deadline — see [Stages.Deadlines];
+//   - PhaseInProgress        some stage is False for another reason, such as
+//     waiting on a dependency;
+//   - PhaseReadyWithWarnings every stage is passing but an advisory one — see
+//     [Stages.Advisory];
+//   - PhaseReady             every stage is passing.
+//
+// Advisory stages count only towards the last two.
+//
+// A resource whose aggregate carries the [Stages.Deleting] reason is
+// PhaseTerminating whatever its stages say: [Stages.Teardown] is walking them
+// backwards, and a failed teardown step is still part of a deletion.
+//
+// With [Stages.RequireCurrentGeneration] set it is [Stages.PhaseAt] for the
+// newest generation any stage or the aggregate was recorded for, the same as
+// [Stages.Aggregate].
 func (s Stages) Phase(conds []metav1.Condition) string {
-	switch s.Aggregate(conds) {
+	if s.RequireCurrentGeneration {
+		return s.PhaseAt(conds, s.observedGeneration(conds))
+	}
+	return s.PhaseAt(conds, 0)
+}
+
+// PhaseAt is [Stages.Phase] for the reconcile of generation: with
+// [Stages.RequireCurrentGeneration] set, a resource that would be PhaseReady is
+// PhaseInProgress while some stage was last recorded for an older generation.
+func (s Stages) PhaseAt(conds []metav1.Condition, generation int64) string {
+	if c := Get(conds, s.readyType()); c != nil && c.Reason == s.deleting() {
+		return PhaseTerminating
+	}
+
+	switch s.aggregate(conds) {
 	case metav1.ConditionTrue:
+		if s.anyStale(conds, generation) {
+			return PhaseInProgress
+		}
+		for _, t := range s.Advisory {
+			if s.notPassing(Get(conds, t)) {
+				return PhaseReadyWithWarnings
+			}
+		}
 		return PhaseReady
 	case metav1.ConditionUnknown:
 		return PhasePending
 	}
 
-	for _, t := range s.Types {
-		if c := Get(conds, t); c != nil &&
-			c.Status == metav1.ConditionFalse &&
-			c.Reason == s.failed() {
+	stalled := false
+	for _, t := range s.required() {
+		c := Get(conds, t)
+		if s.notPassing(c) && c.Reason == s.failed() {
 			return PhaseError
 		}
+		stalled = stalled || s.stalled(conds, c)
+	}
+	if stalled {
+		return PhaseStalled
 	}
 	return PhaseInProgress
 }
@@ -303,8 +860,8 @@ func (s Stages) Phase(conds []metav1.Condition) string {
 // for the end of a clean pass.
 //
 // message is what the stage has to say for itself and is what `kubectl describe`
-// shows next to it. It must not carry secrets, and is truncated to
-// [MaxMessageLen].
+// shows next to it. It should not carry secrets; it goes through
+// [Stages.Redactor] and is truncated to [MaxMessageLen].
 func (s Stages) Pass(conds *[]metav1.Condition, generation int64, stage, message string) {
 	s.set(conds, generation, stage, metav1.ConditionTrue, s.passed(), message)
 }
@@ -313,10 +870,10 @@ func (s Stages) Pass(conds *[]metav1.Condition, generation int64, stage, message
 // of it along with the aggregate — see [Stages.Gate].
 //
 // The error text becomes the stage's message, so err should be wrapped with
-// enough context to be readable in `kubectl describe`, and must not carry
-// secrets: conditions are readable by anyone who can get the resource, and a
-// backend error routinely echoes the request it failed on. It is truncated to
-// [MaxMessageLen].
+// enough context to be readable in `kubectl describe`. A backend error routinely
+// echoes the request it failed on, and conditions are readable by anyone who can
+// get the resource, so the text goes through [Stages.Redactor] before it is
+// truncated to [MaxMessageLen].
 //
 // A nil err records a failure with no detail rather than panicking: this runs
 // inside a reconcile, where taking the controller down is the worse of the two.
@@ -327,7 +884,7 @@ func (s Stages) Fail(conds *[]metav1.Condition, generation int64, stage string,
 	}
 
 	s.set(conds, generation, stage, metav1.ConditionFalse, s.failed(), msg)
-	s.Gate(conds, generation, stage)
+	s.gateOrWarn(conds, generation, stage)
 }
 
 // Wait records a stage that has not finished and did not fail, and gates
@@ -340,6 +897,16 @@ func (s Stages) Wait(conds *[]metav1.Condition, generation int64, stage, reason,
 	}
 
 	s.set(conds, generation, stage, metav1.ConditionFalse, reason, message)
+	s.gateOrWarn(conds, generation, stage)
+}
+
+// gateOrWarn follows up a stage that did not pass: an advisory stage blocks
+// nothing and goes into the advisory aggregate, any other is gated on.
+func (s Stages) gateOrWarn(conds *[]metav1.Condition, generation int64, stage string) {
+	if s.advisory(stage) {
+		s.publish(conds, s.HealthyCondition(*conds, generation), true)
+		return
+	}
 	s.Gate(conds, generation, stage)
 }
 
@@ -375,7 +942,9 @@ func (s Stages) Advance(
 }
 
 // Gate marks every stage after afterStage as False with the [Stages.Blocked]
-// reason, and rewrites the aggregate.
+// reason, and rewrites the aggregate. With [Stages.Requires] set, only the
+// stages that transitively require afterStage are marked; the others keep what
+// their own pass said.
 //
 // The stage named by afterStage is left alone: whoever gated on it has already
 // said why. A condition type that is not in Types is left alone too, which is
@@ -399,22 +968,32 @@ func (s Stages) Gate(conds *[]metav1.Condition, generation int64, afterStage str
 		}
 	}
 	if after >= 0 {
+		blocked := map[string]bool{afterStage: true}
 		for _, t := range s.Types[after:] {
-			s.set(conds, generation, t, metav1.ConditionFalse, s.blocked(), msg)
+			if s.advisory(t) {
+				continue
+			}
+			for _, req := range s.prerequisites(t) {
+				if blocked[req] {
+					blocked[t] = true
+					s.publish(conds, s.condition(generation, t, metav1.ConditionFalse, s.blocked(), msg), false)
+					break
+				}
+			}
 		}
 	}
 
 	cond := s.ReadyCondition(*conds, generation)
-	if cond.Status == metav1.ConditionTrue {
+	if cond.Status == metav1.ConditionTrue && !s.advisory(afterStage) {
 		// Only reachable when afterStage is not one of Types — a caller's typo,
 		// which [Stages.Validate] is there to catch. The stage conditions then
 		// know nothing about the failure that got here, so they all read True.
 		// Say the least misleading thing rather than call the resource ready.
 		cond.Status = metav1.ConditionFalse
 		cond.Reason = s.blocked()
-		cond.Message = TruncateMessage(msg)
+		cond.Message = s.sanitize(msg)
 	}
-	Set(conds, cond)
+	s.publish(conds, cond, true)
 }
 
 func (s Stages) set(
@@ -424,11 +1003,83 @@ func (s Stages) set(
 	status metav1.ConditionStatus,
 	reason, message string,
 ) {
-	Set(conds, metav1.Condition{
+	s.publish(conds, s.condition(generation, condType, status, reason, message), true)
+}
+
+func (s Stages) condition(
+	generation int64,
+	condType string,
+	status metav1.ConditionStatus,
+	reason, message string,
+) metav1.Condition {
+	return metav1.Condition{
 		Type:               condType,
-		Status:             status,
+		Status:             s.polar(condType, status),
 		Reason:             reason,
-		Message:            TruncateMessage(message),
+		Message:            s.sanitize(message),
 		ObservedGeneration: generation,
-	})
+	}
+}
+
+// publish writes cond, exports it to the Metrics and, if it is a transition,
+// logs it to the History and — unless event is false — hands it to the Recorder.
+func (s Stages) publish(conds *[]metav1.Condition, cond metav1.Condition, event bool) bool {
+	// Copied: Get points into the slice Set is about to overwrite.
+	var prev *metav1.Condition
+	if c := Get(*conds, cond.Type); c != nil {
+		cp := *c
+		prev = &cp
+	}
+
+	// Stamped here rather than left to Set, which would use the wall clock:
+	// Deadlines, the Metrics and Render measure the transition against
+	// [Stages.Now], and the two have to agree.
+	if cond.LastTransitionTime.IsZero() && (prev == nil || prev.Status != cond.Status) {
+		cond.LastTransitionTime = metav1.NewTime(s.now())
+	}
+	changed := Set(conds, cond)
+	if s.Metrics != nil {
+		s.Metrics.observe(s, prev, cond)
+	}
+	if prev != nil && prev.Status == cond.Status {
+		return changed
+	}
+
+	if s.History != nil {
+		s.History.Record(*Get(*conds, cond.Type), s.HistoryLimit)
+	}
+	if event {
+		s.record(*conds, cond)
+	}
+	return changed
+}
+
+// unpublish removes the condition of condType, the counterpart of publish for a
+// stage that has nothing left to report: its series leave the Metrics, and the
+// removal is logged to the History and handed to the Recorder as a transition
+// to absent, the status [IsUnknown] reads.
+func (s Stages) unpublish(conds *[]metav1.Condition, generation int64, condType string) bool {
+	prev := Get(*conds, condType)
+	if prev == nil {
+		return false
+	}
+	if s.Metrics != nil {
+		s.Metrics.forget(s, *prev)
+	}
+	Remove(conds, condType)
+
+	if s.History != nil {
+		s.History.Record(metav1.Condition{
+			Type:               condType,
+			Status:             metav1.ConditionUnknown,
+			Reason:             s.deleting(),
+			Message:            "torn down",
+			LastTransitionTime: metav1.NewTime(s.now()),
+			ObservedGeneration: generation,
+		}, s.HistoryLimit)
+	}
+	if s.Recorder != nil {
+		s.Recorder.Event(corev1.EventTypeNormal, s.deleting(), condType+" is torn down")
+	}
+	return true
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -27,6 +28,17 @@ func find(t *testing.T, conds []metav1.Condition, condType string) metav1.Condit
 	return *c
 }
 
+// passed is what s leaves on a resource at generation 1 once every stage has
+// passed.
+func passed(s conditions.Stages) []metav1.Condition {
+	var conds []metav1.Condition
+	for _, t := range s.Types {
+		s.Pass(&conds, 1, t, "")
+	}
+	s.SetReady(&conds, 1, "")
+	return conds
+}
+
 // The zero vocabulary has to be this package's own, so that a caller who fills
 // only Types gets what the package-level functions give. Asserted through the
 // conditions that come out, since the reason strings are what a module's alerts
@@ -628,3 +640,531 @@ func TestAdvanceTruncatesALongMessage(t *testing.T) {
 		}
 	}
 }
+
+// Two independent branches feeding a final stage: the shape a chain cannot
+// express without reporting one branch as blocked by the other.
+func TestRequires(t *testing.T) {
+	s := conditions.Stages{
+		Types: []string{"NodePrepared", "VolumeGroupCreated", "ThinPoolCreated", "Published"},
+		Requires: map[string][]string{
+			"ThinPoolCreated": {"VolumeGroupCreated"},
+			"Published":       {"NodePrepared", "ThinPoolCreated"},
+		},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("the graph should be usable: %v", err)
+	}
+
+	t.Run("Gate blocks only what requires the stage", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Pass(&conds, 1, "NodePrepared", "done")
+		s.Fail(&conds, 1, "VolumeGroupCreated", errors.New("no free disks"))
+
+		for _, blocked := range []string{"ThinPoolCreated", "Published"} {
+			if got := find(t, conds, blocked).Reason; got != conditions.ReasonWaitingForDependency {
+				t.Errorf("%s reason = %q, want %q", blocked, got, conditions.ReasonWaitingForDependency)
+			}
+		}
+		if got := find(t, conds, "NodePrepared").Status; got != metav1.ConditionTrue {
+			t.Errorf("NodePrepared does not require the failed stage and must keep its verdict, got %q", got)
+		}
+	})
+
+	t.Run("an independent branch is not blocked by the other one", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Fail(&conds, 1, "NodePrepared", errors.New("kernel module missing"))
+
+		if conditions.Get(conds, "VolumeGroupCreated") != nil || conditions.Get(conds, "ThinPoolCreated") != nil {
+			t.Errorf("the other branch must be left alone, got %v", types(conds))
+		}
+		if !s.Runnable(conds, "VolumeGroupCreated") {
+			t.Error("VolumeGroupCreated requires nothing and should be runnable")
+		}
+		if s.Runnable(conds, "Published") {
+			t.Error("Published requires the failed stage and should not be runnable")
+		}
+	})
+
+	t.Run("the aggregate names every stuck branch", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Fail(&conds, 1, "NodePrepared", errors.New("kernel module missing"))
+		s.Wait(&conds, 1, "VolumeGroupCreated", "", "scanning disks")
+
+		ready := find(t, conds, conditions.TypeReady)
+		for _, want := range []string{"NodePrepared: kernel module missing", "VolumeGroupCreated: scanning disks"} {
+			if !strings.Contains(ready.Message, want) {
+				t.Errorf("the aggregate should name %q, got %q", want, ready.Message)
+			}
+		}
+		// The blocked stages are stuck only because of the roots, and naming
+		// them would bury the two that need looking at.
+		for _, blocked := range []string{"ThinPoolCreated", "Published"} {
+			if strings.Contains(ready.Message, blocked) {
+				t.Errorf("the aggregate should not name %s, got %q", blocked, ready.Message)
+			}
+		}
+		if ready.Reason != conditions.ReasonReconcileFailed {
+			t.Errorf("reason = %q, want %q", ready.Reason, conditions.ReasonReconcileFailed)
+		}
+	})
+
+	t.Run("every branch passing is ready", func(t *testing.T) {
+		var conds []metav1.Condition
+		for _, stage := range s.Types {
+			if !s.Runnable(conds, stage) {
+				t.Fatalf("%s should be runnable once what it requires passed", stage)
+			}
+			s.Pass(&conds, 1, stage, "done")
+		}
+		s.SetReady(&conds, 1, "")
+
+		if got := s.Phase(conds); got != conditions.PhaseReady {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseReady)
+		}
+	})
+}
+
+// A chain is a graph where each stage requires the one before it, and has to
+// keep reading as exactly that.
+func TestAChainNamesOnlyTheFirstStuckStage(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A", "B", "C"}}
+	conds := []metav1.Condition{
+		{Type: "A", Status: metav1.ConditionTrue},
+		{Type: "B", Status: metav1.ConditionFalse, Reason: "Pending", Message: "first"},
+		{Type: "C", Status: metav1.ConditionFalse, Reason: "Pending", Message: "second"},
+	}
+
+	if got := s.ReadyCondition(conds, 1).Message; got != "B: first" {
+		t.Errorf("message = %q, want %q", got, "B: first")
+	}
+}
+
+func TestValidateRequires(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		requires map[string][]string
+	}{
+		{"a requirement on an unknown stage", map[string][]string{"B": {"Typo"}}},
+		{"requirements for an unknown stage", map[string][]string{"Typo": {"A"}}},
+		{"a requirement listed after the stage", map[string][]string{"A": {"B"}}},
+		{"a stage requiring itself", map[string][]string{"B": {"B"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := conditions.Stages{Types: []string{"A", "B"}, Requires: tc.requires}
+			if err := s.Validate(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRequireCurrentGeneration(t *testing.T) {
+	strict := conditions.Stages{Types: []string{"A", "B"}, RequireCurrentGeneration: true}
+	lenient := conditions.Stages{Types: []string{"A", "B"}}
+
+	// Both stages passed for generation 3, and the spec has since moved to 4.
+	conds := []metav1.Condition{
+		{Type: "A", Status: metav1.ConditionTrue, Reason: "Reconciled", ObservedGeneration: 4},
+		{Type: "B", Status: metav1.ConditionTrue, Reason: "Reconciled", ObservedGeneration: 3},
+	}
+
+	t.Run("a stage passed for an older generation is not passed", func(t *testing.T) {
+		if got := strict.AggregateAt(conds, 4); got != metav1.ConditionFalse {
+			t.Errorf("AggregateAt = %q, want False", got)
+		}
+		if got := strict.PhaseAt(conds, 4); got != conditions.PhaseInProgress {
+			t.Errorf("PhaseAt = %q, want %q", got, conditions.PhaseInProgress)
+		}
+
+		ready := strict.ReadyCondition(conds, 4)
+		if ready.Status != metav1.ConditionFalse || ready.Reason != conditions.ReasonPending {
+			t.Errorf("Ready = %+v, want False/%s", ready, conditions.ReasonPending)
+		}
+		if ready.Message != "B has not observed generation 4" {
+			t.Errorf("message = %q", ready.Message)
+		}
+	})
+
+	// Phase and Aggregate are not told the generation, and are what events and
+	// the matchers read: they measure against the newest one a stage recorded.
+	t.Run("Phase and Aggregate measure against the newest stage", func(t *testing.T) {
+		if got := strict.Phase(conds); got != conditions.PhaseInProgress {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseInProgress)
+		}
+		if got := strict.Aggregate(conds); got != metav1.ConditionFalse {
+			t.Errorf("Aggregate = %q, want False", got)
+		}
+		if got := lenient.Phase(conds); got != conditions.PhaseReady {
+			t.Errorf("Phase without the option = %q, want %q", got, conditions.PhaseReady)
+		}
+
+		caughtUp := append([]metav1.Condition(nil), conds...)
+		caughtUp[1].ObservedGeneration = 4
+		if got := strict.Phase(caughtUp); got != conditions.PhaseReady {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseReady)
+		}
+	})
+
+	t.Run("every stage at the current generation is ready", func(t *testing.T) {
+		if got := strict.AggregateAt(conds, 3); got != metav1.ConditionTrue {
+			t.Errorf("AggregateAt = %q, want True", got)
+		}
+		if got := strict.PhaseAt(conds, 3); got != conditions.PhaseReady {
+			t.Errorf("PhaseAt = %q, want %q", got, conditions.PhaseReady)
+		}
+	})
+
+	// The mode is opt-in, so the modules already on Stages see no change.
+	t.Run("without the option the generation is ignored", func(t *testing.T) {
+		if got := lenient.AggregateAt(conds, 4); got != metav1.ConditionTrue {
+			t.Errorf("AggregateAt = %q, want True", got)
+		}
+		if got := lenient.ReadyCondition(conds, 4).Status; got != metav1.ConditionTrue {
+			t.Errorf("Ready = %q, want True", got)
+		}
+	})
+
+	// What a failed stage said about the older generation is still the most
+	// useful thing to show.
+	t.Run("a failed stage keeps its own message", func(t *testing.T) {
+		failed := []metav1.Condition{
+			{Type: "A", Status: metav1.ConditionFalse, Reason: "ReconcileFailed", Message: "boom", ObservedGeneration: 3},
+		}
+		if got := strict.ReadyCondition(failed, 4).Message; got != "A: boom" {
+			t.Errorf("message = %q, want %q", got, "A: boom")
+		}
+	})
+
+	t.Run("a reconcile that walks every stage catches up", func(t *testing.T) {
+		c := append([]metav1.Condition(nil), conds...)
+		strict.Pass(&c, 4, "A", "done")
+		strict.Pass(&c, 4, "B", "done")
+		strict.SetReady(&c, 4, "")
+
+		if got := find(t, c, conditions.TypeReady).Status; got != metav1.ConditionTrue {
+			t.Errorf("Ready = %q, want True", got)
+		}
+	})
+}
+
+func TestDeadlines(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// B waits with a deadline of ten minutes, having gone False at start, with
+	// the clock moved on to now.
+	waitingOnB := func(now time.Time) (conditions.Stages, []metav1.Condition) {
+		clock := start
+		s := conditions.Stages{
+			Types:     []string{"A", "B", "C"},
+			Deadlines: map[string]time.Duration{"B": 10 * time.Minute},
+			Now:       func() time.Time { return clock },
+		}
+		var conds []metav1.Condition
+		s.Pass(&conds, 1, "A", "")
+		s.Wait(&conds, 1, "B", "DeviceNotEmpty", "the disk has a partition table")
+		clock = now
+		return s, conds
+	}
+
+	t.Run("before the deadline the stage is in progress", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(4 * time.Minute))
+
+		if got := s.Phase(conds); got != conditions.PhaseInProgress {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseInProgress)
+		}
+		if got := s.RequeueAfter(conds); got != 6*time.Minute {
+			t.Errorf("RequeueAfter = %s, want 6m0s", got)
+		}
+	})
+
+	t.Run("past the deadline it has stalled", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(10 * time.Minute))
+
+		if got := s.Phase(conds); got != conditions.PhaseStalled {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseStalled)
+		}
+		ready := s.ReadyCondition(conds, 1)
+		if ready.Reason != conditions.ReasonStalled {
+			t.Errorf("Ready reason = %q, want %q", ready.Reason, conditions.ReasonStalled)
+		}
+		const want = "B has been in progress for longer than 10m0s: the disk has a partition table"
+		if ready.Message != want {
+			t.Errorf("Ready message = %q, want %q", ready.Message, want)
+		}
+		if got := find(t, conds, "B").Reason; got != "DeviceNotEmpty" {
+			t.Errorf("the stage itself should keep its reason, got %q", got)
+		}
+		if got := s.RequeueAfter(conds); got != 0 {
+			t.Errorf("RequeueAfter = %s, want nothing left to wait for", got)
+		}
+	})
+
+	t.Run("a failure takes precedence over a stall", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(time.Hour))
+		s.Types = append(s.Types, "D")
+		s.Requires = map[string][]string{"B": {"A"}, "C": {"B"}}
+		s.Fail(&conds, 1, "D", errors.New("boom"))
+
+		if got := s.Phase(conds); got != conditions.PhaseError {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseError)
+		}
+		if got := s.ReadyCondition(conds, 1).Reason; got != conditions.ReasonReconcileFailed {
+			t.Errorf("Ready reason = %q, want %q", got, conditions.ReasonReconcileFailed)
+		}
+	})
+
+	t.Run("an advisory stage is not requeued for", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(time.Minute))
+		s.Advisory = []string{"B"}
+
+		if got := s.RequeueAfter(conds); got != 0 {
+			t.Errorf("RequeueAfter = %s, want 0", got)
+		}
+		s.Now = func() time.Time { return start.Add(time.Hour) }
+		if got := s.Phase(conds); got == conditions.PhaseStalled {
+			t.Errorf("Phase = %q, an advisory stage must not stall", got)
+		}
+	})
+
+	t.Run("a stage without a deadline never stalls", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(24 * time.Hour))
+		s.Deadlines = nil
+
+		if got := s.Phase(conds); got != conditions.PhaseInProgress {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseInProgress)
+		}
+		if got := s.RequeueAfter(conds); got != 0 {
+			t.Errorf("RequeueAfter = %s, want 0", got)
+		}
+	})
+
+	// Await waits with the reason Gate blocks with; a stage stuck on an outside
+	// dependency is still stuck on its own account.
+	t.Run("a stage awaiting a dependency stalls", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(time.Hour))
+		s.Await(&conds, 1, "B", conditions.Dependency{
+			Verdict: conditions.DependencyMissing,
+			Message: "LVMVolumeGroup vg-0 not found",
+		}, nil)
+
+		if got := find(t, conds, "B").Reason; got != conditions.ReasonWaitingForDependency {
+			t.Fatalf("B reason = %q", got)
+		}
+		if got := s.Phase(conds); got != conditions.PhaseStalled {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseStalled)
+		}
+		if got := s.ReadyCondition(conds, 1).Reason; got != conditions.ReasonStalled {
+			t.Errorf("Ready reason = %q, want %q", got, conditions.ReasonStalled)
+		}
+
+		s.Now = func() time.Time { return start.Add(time.Minute) }
+		if got := s.RequeueAfter(conds); got != 9*time.Minute {
+			t.Errorf("RequeueAfter = %s, want 9m0s", got)
+		}
+	})
+
+	t.Run("a blocked stage is not stuck on its own account", func(t *testing.T) {
+		s, conds := waitingOnB(start.Add(time.Hour))
+		s.Deadlines["C"] = time.Minute
+
+		if got := s.RequeueAfter(conds); got != 0 {
+			t.Errorf("RequeueAfter = %s, want 0", got)
+		}
+		if got := find(t, conds, "C").Reason; got != conditions.ReasonWaitingForDependency {
+			t.Fatalf("C reason = %q", got)
+		}
+		if msg := s.ReadyCondition(conds, 1).Message; strings.Contains(msg, "C has been") {
+			t.Errorf("Ready message = %q, want only B named", msg)
+		}
+	})
+}
+
+func TestValidateDeadlines(t *testing.T) {
+	s := conditions.Stages{Types: []string{"A"}, Deadlines: map[string]time.Duration{"X": time.Minute}}
+	if err := s.Validate(); err == nil {
+		t.Error("a deadline for an unknown stage should be reported")
+	}
+	s.Deadlines = map[string]time.Duration{"A": 0}
+	if err := s.Validate(); err == nil {
+		t.Error("a zero deadline should be reported")
+	}
+}
+
+func TestNegativePolarity(t *testing.T) {
+	s := conditions.Stages{
+		Types:    []string{"Provisioned", "DiskPressure", "Published"},
+		Negative: []string{"DiskPressure"},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	t.Run("passing a negative stage records it False", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Pass(&conds, 1, "Provisioned", "")
+		s.Pass(&conds, 1, "DiskPressure", "80% used")
+		s.Pass(&conds, 1, "Published", "")
+		s.SetReady(&conds, 1, "")
+
+		if c := find(t, conds, "DiskPressure"); c.Status != metav1.ConditionFalse {
+			t.Errorf("DiskPressure = %s, want False", c.Status)
+		}
+		if got := s.Aggregate(conds); got != metav1.ConditionTrue {
+			t.Errorf("Aggregate = %s, want True", got)
+		}
+		if got := s.Phase(conds); got != conditions.PhaseReady {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseReady)
+		}
+		if !s.Passing(conds, "DiskPressure") || !s.Runnable(conds, "Published") {
+			t.Error("a False negative stage should pass and let the next one run")
+		}
+	})
+
+	t.Run("a True negative stage is what blocks", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Pass(&conds, 1, "Provisioned", "")
+		s.Wait(&conds, 1, "DiskPressure", "ThinPoolOverfilled", "97% used")
+
+		c := find(t, conds, "DiskPressure")
+		if c.Status != metav1.ConditionTrue || c.Reason != "ThinPoolOverfilled" {
+			t.Errorf("DiskPressure = %s/%s, want True/ThinPoolOverfilled", c.Status, c.Reason)
+		}
+		if got := find(t, conds, "Published").Status; got != metav1.ConditionFalse {
+			t.Errorf("Published = %s, want it blocked as False", got)
+		}
+		ready := find(t, conds, conditions.TypeReady)
+		if ready.Status != metav1.ConditionFalse || ready.Message != "DiskPressure: 97% used" {
+			t.Errorf("Ready = %s %q", ready.Status, ready.Message)
+		}
+		if got := s.Phase(conds); got != conditions.PhaseInProgress {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseInProgress)
+		}
+	})
+
+	t.Run("a failed negative stage reads as an error", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Pass(&conds, 1, "Provisioned", "")
+		s.Fail(&conds, 1, "DiskPressure", errors.New("statfs: input/output error"))
+
+		if got := find(t, conds, "DiskPressure").Status; got != metav1.ConditionTrue {
+			t.Errorf("DiskPressure = %s, want True", got)
+		}
+		if got := s.Phase(conds); got != conditions.PhaseError {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseError)
+		}
+	})
+
+	t.Run("Unknown means the same either way", func(t *testing.T) {
+		conds := []metav1.Condition{
+			cond("Provisioned", metav1.ConditionTrue, conditions.ReasonReconciled, ""),
+			cond("DiskPressure", metav1.ConditionUnknown, conditions.ReasonPending, ""),
+			cond("Published", metav1.ConditionTrue, conditions.ReasonReconciled, ""),
+		}
+		if got := s.Aggregate(conds); got != metav1.ConditionUnknown {
+			t.Errorf("Aggregate = %s, want Unknown", got)
+		}
+	})
+
+	t.Run("Validate rejects an unknown negative stage", func(t *testing.T) {
+		bad := s
+		bad.Negative = []string{"Degraded"}
+		if err := bad.Validate(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestAdvisoryStages(t *testing.T) {
+	s := conditions.Stages{
+		Types:    []string{"VGCreated", "ThinPoolUsage", "Published"},
+		Advisory: []string{"ThinPoolUsage"},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	t.Run("a warning leaves Ready True", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Pass(&conds, 1, "VGCreated", "")
+		s.Wait(&conds, 1, "ThinPoolUsage", "ThinPoolAboveThreshold", "thin pool is 85% full")
+		if !s.Runnable(conds, "Published") {
+			t.Fatal("an advisory stage must not block the stage after it")
+		}
+		s.Pass(&conds, 1, "Published", "")
+		s.SetReady(&conds, 1, "")
+
+		if got := find(t, conds, "Published").Reason; got != conditions.ReasonReconciled {
+			t.Errorf("Published reason = %q, want it untouched by the warning", got)
+		}
+		if !conditions.IsTrue(conds, conditions.TypeReady) {
+			t.Errorf("Ready = %+v, want True", find(t, conds, conditions.TypeReady))
+		}
+		healthy := find(t, conds, conditions.TypeHealthy)
+		if healthy.Status != metav1.ConditionFalse || healthy.Reason != "ThinPoolAboveThreshold" ||
+			healthy.Message != "ThinPoolUsage: thin pool is 85% full" {
+			t.Errorf("Healthy = %+v", healthy)
+		}
+		if got := s.Phase(conds); got != conditions.PhaseReadyWithWarnings {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseReadyWithWarnings)
+		}
+	})
+
+	t.Run("a cleared warning reads Ready and Healthy", func(t *testing.T) {
+		var conds []metav1.Condition
+		for _, st := range s.Types {
+			s.Pass(&conds, 1, st, "")
+		}
+		s.SetReady(&conds, 1, "")
+
+		if !conditions.IsTrue(conds, conditions.TypeHealthy) {
+			t.Errorf("Healthy = %+v, want True", find(t, conds, conditions.TypeHealthy))
+		}
+		if got := s.Phase(conds); got != conditions.PhaseReady {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseReady)
+		}
+	})
+
+	t.Run("a required stage still blocks", func(t *testing.T) {
+		var conds []metav1.Condition
+		s.Fail(&conds, 1, "VGCreated", errors.New("vgcreate: device busy"))
+
+		if got := find(t, conds, "Published").Reason; got != conditions.ReasonWaitingForDependency {
+			t.Errorf("Published reason = %q, want it blocked", got)
+		}
+		if got := types(conds); strings.Contains(strings.Join(got, ","), "ThinPoolUsage") {
+			t.Errorf("the advisory stage should be left alone, got %v", got)
+		}
+		if got := s.Phase(conds); got != conditions.PhaseError {
+			t.Errorf("Phase = %q, want %q", got, conditions.PhaseError)
+		}
+	})
+
+	t.Run("no Healthy without advisory stages", func(t *testing.T) {
+		plain := conditions.Stages{Types: []string{"A"}}
+		var conds []metav1.Condition
+		plain.Pass(&conds, 1, "A", "")
+		plain.SetReady(&conds, 1, "")
+		if conditions.Get(conds, conditions.TypeHealthy) != nil {
+			t.Error("Healthy published for a stage set with no advisory stage")
+		}
+	})
+}
+
+func TestValidateAdvisory(t *testing.T) {
+	s := conditions.Stages{
+		Types:    []string{"VGCreated", "ThinPoolUsage", "Published"},
+		Advisory: []string{"ThinPoolUsage"},
+		Requires: map[string][]string{"Published": {"ThinPoolUsage"}},
+	}
+	if err := s.Validate(); err == nil {
+		t.Error("requiring an advisory stage should be reported")
+	}
+
+	s = conditions.Stages{
+		Types:    []string{"VGCreated", "ThinPoolUsage", "Published"},
+		Advisory: []string{"Nope"},
+	}
+	if err := s.Validate(); err == nil {
+		t.Error("an unknown advisory stage should be reported")
+	}
+}
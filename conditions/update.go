@@ -2,9 +2,14 @@ package conditions
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,6 +35,15 @@ import (
 // once. It is called with an object read from the API server, so it must cope
 // with a nil status pointer.
 //
+// A kind without a status subresource — a CRD installed from an old chart in an
+// older cluster — answers the write with NotFound, and a controller that treats
+// that as "the object is gone" never publishes a status on it at all. The
+// object was there a moment ago, so the write falls back to a JSON merge patch
+// of only .status on the resource itself, which is where such a kind keeps its
+// status. The patch carries the resourceVersion that was read, so it conflicts
+// and is retried the same as the update would. An object that really was
+// deleted in between fails the patch the same way.
+//
 // obj itself is left untouched — it is only used for its key and its type.
 // Callers that need the written state must re-read the object.
 func UpdateStatus[T client.Object](
@@ -38,9 +52,61 @@ func UpdateStatus[T client.Object](
 	obj T,
 	mutate func(T),
 ) error {
+	return updateStatus(ctx, readFresh(cl, obj), writeStatus[T](cl), mutate)
+}
+
+// writeStatus is the write behind [UpdateStatus], fallback included.
+func writeStatus[T client.Object](cl client.Client) func(ctx context.Context, before, fresh T) error {
+	return func(ctx context.Context, before, fresh T) error {
+		err := cl.Status().Update(ctx, fresh)
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		patch, err := lockedStatusMergePatch(before, fresh)
+		if err != nil {
+			return err
+		}
+		return cl.Patch(ctx, fresh, patch)
+	}
+}
+
+// PatchStatus is [UpdateStatus] with patch semantics: only what mutate changed
+// under .status is sent, as a JSON merge patch, so a status field that another
+// actor owns and mutate did not touch cannot be reverted by a write that raced
+// with it.
+//
+// Use it on resources whose status more than one controller writes to. The
+// price is that a merge patch replaces a list as a whole: two writers of the
+// same list — conditions included — still overwrite each other, and
+// [ApplyStatus] is what merges conditions by type.
+//
+// It falls back to patching the resource itself on a kind without a status
+// subresource, the same as UpdateStatus.
+func PatchStatus[T client.Object](
+	ctx context.Context,
+	cl client.Client,
+	obj T,
+	mutate func(T),
+) error {
+	write := func(ctx context.Context, before, fresh T) error {
+		patch, err := statusMergePatch(before, fresh)
+		if err != nil {
+			return err
+		}
+		err = cl.Status().Patch(ctx, fresh, patch)
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return cl.Patch(ctx, fresh, patch)
+	}
+
+	return updateStatus(ctx, readFresh(cl, obj), write, mutate)
+}
+
+func readFresh[T client.Object](cl client.Client, obj T) func(context.Context) (T, error) {
 	key := client.ObjectKeyFromObject(obj)
 
-	read := func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
 		fresh, ok := obj.DeepCopyObject().(T)
 		if !ok {
 			var zero T
@@ -52,12 +118,6 @@ func UpdateStatus[T client.Object](
 		}
 		return fresh, nil
 	}
-
-	write := func(ctx context.Context, fresh T) error {
-		return cl.Status().Update(ctx, fresh)
-	}
-
-	return UpdateStatusVia(ctx, read, write, mutate)
 }
 
 // UpdateStatusVia is [UpdateStatus] for callers that do not hold a
@@ -84,6 +144,42 @@ func UpdateStatusVia[T client.Object](
 	read func(context.Context) (T, error),
 	write func(context.Context, T) error,
 	mutate func(T),
+) error {
+	return updateStatus(ctx, read, func(ctx context.Context, _, fresh T) error {
+		return write(ctx, fresh)
+	}, mutate)
+}
+
+// PatchStatusVia is [PatchStatus] for callers that do not hold a client.Client,
+// the way [UpdateStatusVia] is for UpdateStatus.
+//
+// patch sends the merge patch it is given — only what mutate changed under
+// .status — for the object it is given, typically through a repository method
+// wrapping client.Patch. A repository that writes a kind without a status
+// subresource sends it to the resource itself rather than to /status.
+func PatchStatusVia[T client.Object](
+	ctx context.Context,
+	read func(context.Context) (T, error),
+	patch func(context.Context, T, client.Patch) error,
+	mutate func(T),
+) error {
+	return updateStatus(ctx, read, func(ctx context.Context, before, fresh T) error {
+		p, err := statusMergePatch(before, fresh)
+		if err != nil {
+			return err
+		}
+		return patch(ctx, fresh, p)
+	}, mutate)
+}
+
+// updateStatus is the loop behind every variant here. write is handed the
+// object as read alongside the mutated one, which is what a patch is computed
+// from.
+func updateStatus[T client.Object](
+	ctx context.Context,
+	read func(context.Context) (T, error),
+	write func(ctx context.Context, before, fresh T) error,
+	mutate func(T),
 ) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		fresh, err := read(ctx)
@@ -106,6 +202,58 @@ func UpdateStatusVia[T client.Object](
 			return nil
 		}
 
-		return write(ctx, fresh)
+		return write(ctx, before, fresh)
 	})
 }
+
+// statusMergePatch is a JSON merge patch taking before's .status to after's,
+// and nothing outside it.
+func statusMergePatch(before, after runtime.Object) (client.Patch, error) {
+	status := func(obj runtime.Object) ([]byte, error) {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("converting %T: %w", obj, err)
+		}
+		return json.Marshal(map[string]any{"status": u["status"]})
+	}
+
+	original, err := status(before)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := status(after)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsonpatch.CreateMergePatch(original, modified)
+	if err != nil {
+		return nil, fmt.Errorf("computing the status patch: %w", err)
+	}
+	return client.RawPatch(types.MergePatchType, data), nil
+}
+
+// lockedStatusMergePatch is statusMergePatch guarded by the resourceVersion of
+// before, the way client.MergeFromWithOptimisticLock guards a patch: a merge
+// patch replaces a list as a whole, so without it a write from stale state
+// would overwrite another actor's conditions instead of conflicting.
+func lockedStatusMergePatch(before, after client.Object) (client.Patch, error) {
+	patch, err := statusMergePatch(before, after)
+	if err != nil {
+		return nil, err
+	}
+	data, err := patch.Data(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("reading the status patch back: %w", err)
+	}
+	fields["metadata"] = map[string]any{"resourceVersion": before.GetResourceVersion()}
+	if data, err = json.Marshal(fields); err != nil {
+		return nil, fmt.Errorf("locking the status patch: %w", err)
+	}
+	return client.RawPatch(types.MergePatchType, data), nil
+}
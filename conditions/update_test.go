@@ -9,12 +9,15 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
+	"github.com/deckhouse/sds-common-lib/api/v1alpha1"
 	"github.com/deckhouse/sds-common-lib/conditions"
 )
 
@@ -316,3 +319,213 @@ func TestUpdateStatusVia_RejectsANilReadWithoutAnError(t *testing.T) {
 		t.Fatalf("the error should name the nil it got, got %q", err)
 	}
 }
+
+// A CRD installed without the status subresource answers a status write with
+// NotFound. The object was read a moment ago, so that is the subresource
+// missing rather than the object, and the status has to land on the resource.
+func TestUpdateStatus_FallsBackToAPatchWithoutAStatusSubresource(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("building the scheme: %v", err)
+	}
+	mc := &v1alpha1.ModuleConfig{ObjectMeta: metav1.ObjectMeta{Name: "sds-node-configurator"}}
+	mc.Spec.Version = 2
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(mc).Build()
+
+	err := conditions.UpdateStatus(context.Background(), cl, mc, func(m *v1alpha1.ModuleConfig) {
+		m.Status.Message = "written without a subresource"
+	})
+	if err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	got := &v1alpha1.ModuleConfig{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(mc), got); err != nil {
+		t.Fatalf("reading the object back: %v", err)
+	}
+	if got.Status.Message != "written without a subresource" {
+		t.Errorf("expected the status to be persisted, got %q", got.Status.Message)
+	}
+	if got.Spec.Version != 2 {
+		t.Errorf("the patch must carry only the status, spec.version is %d", got.Spec.Version)
+	}
+}
+
+// The fallback patch keeps the contract of the update it stands in for: a write
+// from state another actor has since changed conflicts, and is redone from what
+// that actor wrote, rather than overwriting it.
+func TestUpdateStatus_FallbackPatchConflictsOnAStaleRead(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("building the scheme: %v", err)
+	}
+	mc := &v1alpha1.ModuleConfig{ObjectMeta: metav1.ObjectMeta{Name: "sds-node-configurator"}}
+	patches := 0
+	cl := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(mc).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(
+				ctx context.Context,
+				c client.WithWatch,
+				obj client.Object,
+				patch client.Patch,
+				opts ...client.PatchOption,
+			) error {
+				patches++
+				if patches == 1 {
+					other := &v1alpha1.ModuleConfig{}
+					if err := c.Get(ctx, client.ObjectKeyFromObject(obj), other); err != nil {
+						return err
+					}
+					other.Status.Message = "theirs"
+					if err := c.Update(ctx, other); err != nil {
+						return err
+					}
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	err := conditions.UpdateStatus(context.Background(), cl, mc, func(m *v1alpha1.ModuleConfig) {
+		m.Status.Message += "+ours"
+	})
+	if err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	got := &v1alpha1.ModuleConfig{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(mc), got); err != nil {
+		t.Fatalf("reading the object back: %v", err)
+	}
+	if got.Status.Message != "theirs+ours" {
+		t.Errorf("status.message = %q, want the other write kept and ours redone on top", got.Status.Message)
+	}
+	if patches != 2 {
+		t.Errorf("expected the stale patch to conflict and be retried, got %d patches", patches)
+	}
+}
+
+func TestUpdateStatus_ObjectDeletedBeforeTheWriteIsStillNotFound(t *testing.T) {
+	pod := newPod()
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(pod).
+		WithStatusSubresource(&corev1.Pod{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(
+				ctx context.Context,
+				c client.Client,
+				_ string,
+				obj client.Object,
+				_ ...client.SubResourceUpdateOption,
+			) error {
+				if err := c.Delete(ctx, obj); err != nil {
+					return err
+				}
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, obj.GetName())
+			},
+		}).
+		Build()
+
+	err := conditions.UpdateStatus(context.Background(), cl, pod, func(p *corev1.Pod) {
+		p.Status.Message = "written"
+	})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected a NotFound error, got %v", err)
+	}
+}
+
+// The point of patch semantics: a field somebody else set between our read and
+// our write is not in the patch, and so survives it.
+func TestPatchStatus_LeavesFieldsItDidNotChange(t *testing.T) {
+	pod := newPod()
+
+	var sent string
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(pod).
+		WithStatusSubresource(&corev1.Pod{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(
+				ctx context.Context,
+				c client.Client,
+				subResourceName string,
+				obj client.Object,
+				patch client.Patch,
+				opts ...client.SubResourcePatchOption,
+			) error {
+				other := &corev1.Pod{}
+				if err := c.Get(ctx, client.ObjectKeyFromObject(obj), other); err != nil {
+					return err
+				}
+				other.Status.Reason = "SetByAnotherActor"
+				if err := c.Status().Update(ctx, other); err != nil {
+					return err
+				}
+
+				data, err := patch.Data(obj)
+				if err != nil {
+					return err
+				}
+				sent = string(data)
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	err := conditions.PatchStatus(context.Background(), cl, pod, func(p *corev1.Pod) {
+		p.Status.Message = "written"
+	})
+	if err != nil {
+		t.Fatalf("PatchStatus: %v", err)
+	}
+
+	if sent != `{"status":{"message":"written"}}` {
+		t.Errorf("the patch should carry only what mutate changed, got %s", sent)
+	}
+	got := readPod(t, cl)
+	if got.Status.Message != "written" {
+		t.Errorf("expected the mutation to be persisted, got %q", got.Status.Message)
+	}
+	if got.Status.Reason != "SetByAnotherActor" {
+		t.Errorf("the concurrent write was clobbered, reason is %q", got.Status.Reason)
+	}
+}
+
+func TestPatchStatusVia_HandsOverAStatusOnlyMergePatch(t *testing.T) {
+	pod := newPod()
+	cl := newClient(t, pod)
+	ctx := context.Background()
+
+	read := func(ctx context.Context) (*corev1.Pod, error) {
+		got := &corev1.Pod{}
+		err := cl.Get(ctx, client.ObjectKeyFromObject(pod), got)
+		return got, err
+	}
+	var patchType k8stypes.PatchType
+	patch := func(ctx context.Context, p *corev1.Pod, patch client.Patch) error {
+		patchType = patch.Type()
+		return cl.Status().Patch(ctx, p, patch)
+	}
+
+	err := conditions.PatchStatusVia(ctx, read, patch, func(p *corev1.Pod) {
+		p.Labels = map[string]string{"ignored": "outside status"}
+		p.Status.Message = "written through a repository"
+	})
+	if err != nil {
+		t.Fatalf("PatchStatusVia: %v", err)
+	}
+
+	if patchType != k8stypes.MergePatchType {
+		t.Errorf("patch type = %q, want %q", patchType, k8stypes.MergePatchType)
+	}
+	got := readPod(t, cl)
+	if got.Status.Message != "written through a repository" {
+		t.Errorf("expected the mutation to be persisted, got %q", got.Status.Message)
+	}
+	if len(got.Labels) != 0 {
+		t.Errorf("only the status may travel, got labels %v", got.Labels)
+	}
+}
@@ -2,8 +2,11 @@ package cooldown
 
 import (
 	"context"
+	"errors"
 	"iter"
+	"slices"
 	"sync"
+	"time"
 )
 
 type Batcher = BatcherTyped[any]
@@ -11,90 +14,258 @@ type BatchAppend = BatchAppendTyped[any]
 
 type BatchAppendTyped[T any] func(batch []T, newItem T) []T
 
+// Returned by [BatcherTyped.Add], when the buffer is full and the overflow of
+// [WithMaxBuffered] is [OverflowError].
+var ErrBufferFull = errors.New("batcher buffer is full")
+
+// Returned by [BatcherTyped.Add], once the batcher is closed.
+var ErrClosed = errors.New("batcher is closed")
+
 // Buffer, which is populated via [BatcherTyped.Add] and consumed with
 // [BatcherTyped.ConsumeWithCooldown]. Allows batching items (see batchAppend in
 // [NewBatcher]) and consuming them in time-controlled way - with cooldown.
+// See [KeyedBatcher] for batches of the latest item per key.
+//
+// By default batches are unlimited. See [WithMaxBatchSize], [WithMaxItemAge]
+// and [WithMaxBuffered] for the flush policies.
+//
+// Any number of consumers may consume concurrently: each batch goes to one of
+// them, the one which has been waiting the longest. See [PartitionedBatcher]
+// for keeping related items on the same consumer.
 type BatcherTyped[T any] struct {
-	mu          *sync.Mutex
-	cond        *sync.Cond
-	batchAppend BatchAppendTyped[T]
-	items       []T
+	mu      *sync.Mutex
+	cond    *sync.Cond
+	notFull *sync.Cond
+	opts    options
+	buf     batchBuffer[T]
+
+	// mutable:
+
+	// when the buffer became non-empty
+	oldest time.Time
+	// consumers waiting for a batch, in the order they came
+	waiters []*waiter
+	// number of batches taken, but not yet handled by consumers
+	inFlight  int
+	closed    bool
+	closedCh  chan struct{}
+	drained   bool
+	drainedCh chan struct{}
+}
+
+// Storage of the items, which have been added, but not yet taken by consumer.
+type batchBuffer[T any] interface {
+	add(newItem T)
+	len() int
+	// whether adding newItem would make one more pending item
+	grows(newItem T) bool
+	// removes up to n items (all, if n is 0) and returns them
+	take(n int) []T
+	dropOldest()
 }
 
 // Creates new [*BatcherTyped] with batchAppend, which allows modifying current batch
 // buffer before consumer takes it.
-func NewBatcher[T any](batchAppend BatchAppendTyped[T]) *BatcherTyped[T] {
+//
+// Accepts [WithMaxBatchSize], [WithMaxItemAge], [WithMaxBuffered],
+// [WithObserver] and [WithClock].
+func NewBatcher[T any](batchAppend BatchAppendTyped[T], opts ...Option) *BatcherTyped[T] {
 	if batchAppend == nil {
 		batchAppend = func(batch []T, newItem T) []T {
 			return append(batch, newItem)
 		}
 	}
 
+	return newBatcher[T](&appendBuffer[T]{batchAppend: batchAppend}, opts)
+}
+
+func newBatcher[T any](buf batchBuffer[T], opts []Option) *BatcherTyped[T] {
 	mu := &sync.Mutex{}
 	return &BatcherTyped[T]{
-		mu:          mu,
-		cond:        sync.NewCond(mu),
-		batchAppend: batchAppend,
+		mu:      mu,
+		cond:    sync.NewCond(mu),
+		notFull: sync.NewCond(mu),
+		opts:    newOptions(opts),
+		buf:     buf,
+
+		closedCh:  make(chan struct{}),
+		drainedCh: make(chan struct{}),
 	}
 }
 
+// Adds newItem to the buffer. Returns [ErrClosed], if the batcher is closed,
+// and [ErrBufferFull], see [WithMaxBuffered].
 func (b *BatcherTyped[T]) Add(newItem T) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.items = b.batchAppend(b.items, newItem)
+	if b.closed {
+		return ErrClosed
+	}
+
+	for b.full(newItem) {
+		switch b.opts.overflow {
+		case OverflowError:
+			b.opts.observer.ItemsDropped(1, DropRejected, b.buf.len())
+			return ErrBufferFull
+		case OverflowDropOldest:
+			b.buf.dropOldest()
+			b.opts.observer.ItemsDropped(1, DropOverflow, b.buf.len())
+		default:
+			b.notFull.Wait()
+			if b.closed {
+				return ErrClosed
+			}
+		}
+	}
+
+	wasEmpty := b.buf.len() == 0
+	b.buf.add(newItem)
 
 	// let the appender to cancel out the current batch
-	if len(b.items) > 0 {
-		b.cond.Signal()
+	if b.buf.len() > 0 {
+		if wasEmpty {
+			b.oldest = b.opts.clock.Now()
+		}
+		// the first consumer in the queue takes it
+		b.cond.Broadcast()
 	}
+	b.opts.observer.ItemAdded(b.buf.len())
 
 	return nil
 }
 
+func (b *BatcherTyped[T]) full(newItem T) bool {
+	return b.opts.maxBuffered > 0 &&
+		b.buf.len() >= b.opts.maxBuffered &&
+		b.buf.grows(newItem)
+}
+
 // See [BatcherTyped]
+//
+// When ctx is canceled, the iteration ends at once, dropping the pending items.
+// When the batcher is closed (see [BatcherTyped.Close]), the remaining items
+// are yielded without waiting for the cooldown, and then the iteration ends.
 func (b *BatcherTyped[T]) ConsumeWithCooldown(
 	ctx context.Context,
 	cooldown Cooldown,
 ) iter.Seq[[]T] {
 	return func(yield func([]T) bool) {
 		for {
-			items, err := b.waitForItem(ctx)
+			batch, err := b.waitForItem(ctx)
 
 			if err != nil {
-				// context cancelation, ok to drop items
+				// context cancelation or closed and drained
 				return
 			}
 
-			if cooldown != nil {
-				if err := cooldown.Hit(ctx); err != nil {
+			if cooldown != nil && !batch.closing {
+				if err := b.hit(ctx, cooldown, batch.oldest); err != nil {
 					// context cancelation, ok to drop items
+					b.opts.observer.ItemsDropped(len(batch.items), DropCanceled, b.buffered())
+					b.done()
 					return
 				}
 			}
 
-			if !yield(items) {
+			ok := yield(batch.items)
+			b.done()
+			if !ok {
 				return
 			}
 		}
 	}
 }
 
-func (b *BatcherTyped[T]) waitForItem(ctx context.Context) ([]T, error) {
+// Hits cooldown, but gives up waiting, once the batcher is closed, or the item
+// added at oldest reaches the max item age.
+func (b *BatcherTyped[T]) hit(ctx context.Context, cooldown Cooldown, oldest time.Time) error {
+	clock := b.opts.clock
+	start := clock.Now()
+	defer func() {
+		b.opts.observer.CooldownWaited(clock.Now().Sub(start))
+	}()
+
+	hitCtx, cancel := withCancelOn(ctx, b.closedCh)
+	defer cancel()
+
+	if b.opts.maxItemAge > 0 {
+		hitCtx, cancel = withClockTimeout(hitCtx, clock, oldest.Add(b.opts.maxItemAge).Sub(clock.Now()))
+		defer cancel()
+	}
+
+	if err := cooldown.Hit(hitCtx); err != nil && ctx.Err() != nil {
+		return err
+	}
+	// either cooled, or closed, or the batch is too old to wait
+	return nil
+}
+
+// Stops accepting items: [BatcherTyped.Add] returns [ErrClosed] from now on,
+// including the calls blocked on a full buffer. The consumers get the items
+// left in the buffer without waiting for the cooldown, and then their
+// iterations end. Closing a closed batcher does nothing.
+func (b *BatcherTyped[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.closedCh)
+
+	b.cond.Broadcast()
+	b.notFull.Broadcast()
+	b.checkDrained()
+}
+
+// Closes the batcher (see [BatcherTyped.Close]) and waits until the consumers
+// have handled the items left in the buffer: taken them and returned from the
+// loop body. Returns ctx.Err(), if ctx is done first.
+func (b *BatcherTyped[T]) Drain(ctx context.Context) error {
+	b.Close()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.drainedCh:
+		return nil
+	}
+}
+
+// Batch taken from the buffer
+type takenBatch[T any] struct {
+	items []T
+	// when the buffer became non-empty
+	oldest time.Time
+	// batcher is closed, so the batch is not subject to the cooldown
+	closing bool
+}
+
+// Returns the next batch, or ctx.Err(), or [ErrClosed], once the batcher is
+// closed and the buffer is empty.
+//
+// Consumers queue up for batches: a batch goes to the consumer, which has
+// been waiting the longest.
+func (b *BatcherTyped[T]) waitForItem(ctx context.Context) (takenBatch[T], error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if err := ctx.Err(); err != nil {
-		return nil, err
+		return takenBatch[T]{}, err
 	}
 
-	// it has already been triggered, while we were not waiting
-	if len(b.items) > 0 {
-		return b.takeBatch(), nil
+	w := &waiter{}
+	b.waiters = append(b.waiters, w)
+	defer b.leave(w)
+
+	if b.ready(w) {
+		return b.takeBatch()
 	}
 
-	// awakener is a goroutine, which will call "fake" Signal in order to stop
-	// Wait() on context cancelation
+	// awakener is a goroutine, which will call "fake" Broadcast in order to
+	// stop Wait() on context cancelation
 	awakenerDone := make(chan struct{})
 	defer func() {
 		<-awakenerDone
@@ -105,26 +276,130 @@ func (b *BatcherTyped[T]) waitForItem(ctx context.Context) ([]T, error) {
 
 	go func() {
 		<-awakenerCtx.Done()
-		b.cond.Signal()
+		b.cond.Broadcast()
 		awakenerDone <- struct{}{}
 	}()
 
-	b.cond.Wait()
+	for !b.ready(w) {
+		b.cond.Wait()
 
-	if err := ctx.Err(); err != nil {
-		return nil, err
+		if err := ctx.Err(); err != nil {
+			return takenBatch[T]{}, err
+		}
 	}
 
-	return b.takeBatch(), nil
+	return b.takeBatch()
+}
+
+// Consumer waiting in [BatcherTyped.waitForItem]
+type waiter struct {
+	// non-zero size, so that pointers to different waiters are different
+	_ byte
+}
+
+// Whether w may stop waiting: it is first in the queue for a batch, or there
+// will be no more batches.
+func (b *BatcherTyped[T]) ready(w *waiter) bool {
+	if b.buf.len() == 0 {
+		return b.closed
+	}
+	return b.waiters[0] == w
 }
 
-func (b *BatcherTyped[T]) takeBatch() []T {
-	res := make([]T, len(b.items))
-	copy(res, b.items)
+// Removes w from the queue, letting the next consumer to take the rest of the
+// buffer.
+func (b *BatcherTyped[T]) leave(w *waiter) {
+	for i, other := range b.waiters {
+		if other == w {
+			b.waiters = slices.Delete(b.waiters, i, i+1)
+			break
+		}
+	}
+	if b.buf.len() > 0 {
+		b.cond.Broadcast()
+	}
+}
+
+// Takes a batch of up to max batch size items. The batch is in flight, until
+// [BatcherTyped.done] is called.
+func (b *BatcherTyped[T]) takeBatch() (takenBatch[T], error) {
+	if b.buf.len() == 0 {
+		// closed and drained
+		return takenBatch[T]{}, ErrClosed
+	}
+
+	batch := takenBatch[T]{
+		items:   b.buf.take(b.opts.maxBatchSize),
+		oldest:  b.oldest,
+		closing: b.closed,
+	}
+	if b.buf.len() == 0 {
+		b.oldest = time.Time{}
+	}
+	b.opts.observer.BatchTaken(len(batch.items), b.opts.clock.Now().Sub(batch.oldest), b.buf.len())
+	b.inFlight++
+	b.notFull.Broadcast()
+	return batch, nil
+}
+
+func (b *BatcherTyped[T]) buffered() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.len()
+}
+
+// Marks a batch taken by [BatcherTyped.takeBatch] as handled.
+func (b *BatcherTyped[T]) done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight--
+	b.checkDrained()
+}
+
+func (b *BatcherTyped[T]) checkDrained() {
+	if b.closed && !b.drained && b.buf.len() == 0 && b.inFlight == 0 {
+		b.drained = true
+		close(b.drainedCh)
+	}
+}
+
+// [batchBuffer], which items are managed by batchAppend
+type appendBuffer[T any] struct {
+	batchAppend BatchAppendTyped[T]
+	items       []T
+}
+
+func (buf *appendBuffer[T]) add(newItem T) {
+	buf.items = buf.batchAppend(buf.items, newItem)
+}
+
+func (buf *appendBuffer[T]) len() int {
+	return len(buf.items)
+}
+
+func (buf *appendBuffer[T]) grows(T) bool {
+	return true
+}
+
+func (buf *appendBuffer[T]) take(n int) []T {
+	if n <= 0 || n > len(buf.items) {
+		n = len(buf.items)
+	}
+
+	res := make([]T, n)
+	copy(res, buf.items)
 
 	// free elements, but keep the array, which is managed by batchAppend
-	clear(b.items)
-	b.items = b.items[:0]
+	rest := copy(buf.items, buf.items[n:])
+	clear(buf.items[rest:])
+	buf.items = buf.items[:rest]
 
 	return res
 }
+
+func (buf *appendBuffer[T]) dropOldest() {
+	if len(buf.items) > 0 {
+		buf.take(1)
+	}
+}
@@ -2,7 +2,10 @@ package cooldown
 
 import (
 	"context"
+	"errors"
 	"iter"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 )
@@ -49,8 +52,6 @@ func TestBatcher_NoCooldown(t *testing.T) {
 
 func TestBatcher_WithCooldown(t *testing.T) {
 	cdDelay := 100 * time.Millisecond
-	timeout := 550 * time.Millisecond
-	timeDelta := 10 * time.Millisecond
 
 	batcher := NewBatcher(
 		// do not collect items, but always keep one
@@ -61,64 +62,473 @@ func TestBatcher_WithCooldown(t *testing.T) {
 			return []any{true}
 		},
 	)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// adder
-	adderDone := make(chan struct{})
-	errCh := make(chan error, 1)
+	clock := NewFakeClock(time.Unix(0, 0))
+	cooldown := NewExponentialCooldown(cdDelay, cdDelay, WithClock(clock))
 
+	consumed := make(chan time.Time)
 	go func() {
-		for ctx.Err() == nil {
-			err := batcher.Add(true)
-			if err != nil {
-				errCh <- err
+		defer close(consumed)
+		for range batcher.ConsumeWithCooldown(ctx, cooldown) {
+			consumed <- clock.Now()
+		}
+	}()
+
+	addItems := func() {
+		for range 3 {
+			if err := batcher.Add(true); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+		}
+	}
+
+	// first batch is not in cooldown
+	addItems()
+	lastMoment := <-consumed
+	if !lastMoment.Equal(clock.Now()) {
+		t.Fatalf("expected first batch immediately, got it at %v", lastMoment)
+	}
+
+	for range 5 {
+		addItems()
+		clock.BlockUntil(1)
+
+		clock.Advance(cdDelay - time.Nanosecond)
+		select {
+		case <-consumed:
+			t.Fatal("expected batch to wait for the cooldown")
+		default:
+		}
+
+		clock.Advance(time.Nanosecond)
+		moment := <-consumed
+		if delay := moment.Sub(lastMoment); delay != cdDelay {
+			t.Errorf("expected delay %v, got %v", cdDelay, delay)
+		}
+		lastMoment = moment
+	}
+
+	// items, which came while consumer is in cooldown, are dropped on cancel
+	addItems()
+	clock.BlockUntil(1)
+	cancel()
+	if _, ok := <-consumed; ok {
+		t.Fatal("expected no batches after cancelation")
+	}
+}
+
+func TestBatcher_MaxBatchSize(t *testing.T) {
+	batcher := NewBatcher[int](nil, WithMaxBatchSize(4))
+
+	for i := range 10 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next, stop := iter.Pull(batcher.ConsumeWithCooldown(ctx, nil))
+	defer stop()
+
+	var item int
+	for _, size := range []int{4, 4, 2} {
+		batch, ok := next()
+		if !ok {
+			t.Fatal("expected a batch, got nothing")
+		}
+		if len(batch) != size {
+			t.Fatalf("expected batch of size %d, got %v", size, batch)
+		}
+		for _, got := range batch {
+			if got != item {
+				t.Fatalf("expected items in order, expected %d got %d", item, got)
 			}
-			time.Sleep(time.Microsecond)
+			item++
+		}
+	}
+}
+
+func TestBatcher_MaxItemAge(t *testing.T) {
+	maxAge := 100 * time.Millisecond
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	batcher := NewBatcher[int](nil, WithMaxItemAge(maxAge), WithClock(clock))
+	cooldown := NewExponentialCooldown(time.Second, time.Second, WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumed := make(chan time.Time)
+	go func() {
+		defer close(consumed)
+		for range batcher.ConsumeWithCooldown(ctx, cooldown) {
+			consumed <- clock.Now()
 		}
-		adderDone <- struct{}{}
 	}()
 
-	cooldown := NewExponentialCooldown(cdDelay, cdDelay)
+	// first batch starts the cooldown
+	if err := batcher.Add(1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	start := <-consumed
+
+	if err := batcher.Add(2); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
 
-	lastMoment := time.Now()
-	var delays []time.Duration
-	for range batcher.ConsumeWithCooldown(ctx, cooldown) {
-		delays = append(delays, time.Since(lastMoment))
+	// cooldown and item age timers
+	clock.BlockUntil(2)
+	clock.Advance(maxAge)
 
-		lastMoment = time.Now()
+	if delay := (<-consumed).Sub(start); delay != maxAge {
+		t.Errorf("expected batch after max item age %v, got it after %v", maxAge, delay)
 	}
+}
 
-	delays = append(delays, time.Since(lastMoment))
+func TestBatcher_MaxBuffered_Error(t *testing.T) {
+	batcher := NewBatcher[int](nil, WithMaxBuffered(2, OverflowError))
 
-	// assert
-	assertDurationApproximatelyEqual(t, delays[0], 0, timeDelta)
+	for i := range 2 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+	if err := batcher.Add(2); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("expected '%v', got '%v'", ErrBufferFull, err)
+	}
 
-	for i := 1; i < 5; i++ {
-		assertDurationApproximatelyEqual(t, delays[i], cdDelay, timeDelta)
+	batch := consumeOne(t, batcher)
+	if len(batch) != 2 {
+		t.Fatalf("expected the items before overflow, got %v", batch)
 	}
+}
 
-	assertDurationApproximatelyEqual(t, delays[6], cdDelay/2, timeDelta)
+func TestBatcher_MaxBuffered_DropOldest(t *testing.T) {
+	batcher := NewBatcher[int](nil, WithMaxBuffered(3, OverflowDropOldest))
 
-	for {
-		select {
-		case err := <-errCh:
+	for i := range 5 {
+		if err := batcher.Add(i); err != nil {
 			t.Fatalf("unexpected err: %v", err)
-		case <-adderDone:
-			return
 		}
 	}
+
+	batch := consumeOne(t, batcher)
+	if len(batch) != 3 || batch[0] != 2 || batch[2] != 4 {
+		t.Fatalf("expected the newest items [2 3 4], got %v", batch)
+	}
 }
 
-func assertDurationApproximatelyEqual(
-	t *testing.T,
-	actual time.Duration,
-	expected time.Duration,
-	delta time.Duration,
-) {
-	diff := (expected - actual).Abs()
-	delta = delta.Abs()
-	if diff > delta {
-		t.Errorf("expected duration to equal %v ± %v, got %v", expected, delta, actual)
+func TestBatcher_MaxBuffered_Block(t *testing.T) {
+	batcher := NewBatcher[int](nil, WithMaxBuffered(2, OverflowBlock))
+
+	for i := range 2 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	added := make(chan error)
+	go func() {
+		added <- batcher.Add(2)
+	}()
+
+	select {
+	case err := <-added:
+		t.Fatalf("expected Add to block, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if batch := consumeOne(t, batcher); len(batch) != 2 {
+		t.Fatalf("expected the items before overflow, got %v", batch)
+	}
+	if err := <-added; err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if batch := consumeOne(t, batcher); len(batch) != 1 || batch[0] != 2 {
+		t.Fatalf("expected the blocked item, got %v", batch)
+	}
+}
+
+func consumeOne[T any](t *testing.T, batcher *BatcherTyped[T]) []T {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for batch := range batcher.ConsumeWithCooldown(ctx, nil) {
+		return batch
+	}
+	t.Fatal("expected a batch, got nothing")
+	return nil
+}
+
+func TestBatcher_Close(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	batcher := NewBatcher[int](nil, WithMaxBatchSize(2))
+	cooldown := NewExponentialCooldown(time.Hour, time.Hour, WithClock(clock))
+
+	// next hit is in cooldown
+	if err := cooldown.Hit(context.Background()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for i := range 3 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+	batcher.Close()
+	batcher.Close()
+
+	if err := batcher.Add(3); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected '%v', got '%v'", ErrClosed, err)
+	}
+
+	var batches [][]int
+	for batch := range batcher.ConsumeWithCooldown(context.Background(), cooldown) {
+		batches = append(batches, batch)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("expected the remaining items in batches of 2, got %v", batches)
+	}
+}
+
+func TestBatcher_Close_DuringCooldown(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	batcher := NewBatcher[int](nil)
+	cooldown := NewExponentialCooldown(time.Hour, time.Hour, WithClock(clock))
+
+	consumed := make(chan []int)
+	go func() {
+		defer close(consumed)
+		for batch := range batcher.ConsumeWithCooldown(context.Background(), cooldown) {
+			consumed <- batch
+		}
+	}()
+
+	if err := batcher.Add(1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	<-consumed
+
+	if err := batcher.Add(2); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	clock.BlockUntil(1)
+	batcher.Close()
+
+	if batch := <-consumed; len(batch) != 1 || batch[0] != 2 {
+		t.Fatalf("expected the last item without the cooldown, got %v", batch)
+	}
+	if _, ok := <-consumed; ok {
+		t.Fatal("expected the iteration to end")
+	}
+}
+
+func TestBatcher_Close_UnblocksAdd(t *testing.T) {
+	batcher := NewBatcher[int](nil, WithMaxBuffered(1, OverflowBlock))
+
+	if err := batcher.Add(1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	added := make(chan error)
+	go func() {
+		added <- batcher.Add(2)
+	}()
+
+	batcher.Close()
+	if err := <-added; !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected '%v', got '%v'", ErrClosed, err)
+	}
+}
+
+func TestBatcher_Drain(t *testing.T) {
+	batcher := NewBatcher[int](nil)
+
+	for i := range 3 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	// no consumer
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := batcher.Drain(ctx); err != context.Canceled {
+		t.Fatalf("expected '%v', got '%v'", context.Canceled, err)
+	}
+
+	var handled []int
+	release := make(chan struct{})
+	go func() {
+		for batch := range batcher.ConsumeWithCooldown(context.Background(), nil) {
+			<-release
+			handled = append(handled, batch...)
+		}
+	}()
+
+	drained := make(chan error)
+	go func() {
+		drained <- batcher.Drain(context.Background())
+	}()
+
+	select {
+	case err := <-drained:
+		t.Fatalf("expected Drain to wait for the consumer, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(handled) != 3 {
+		t.Fatalf("expected all the items handled, got %v", handled)
+	}
+}
+
+func TestBatcher_MultipleConsumers_FairHandOff(t *testing.T) {
+	consumers := 3
+	batcher := NewBatcher[int](nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type received struct {
+		consumer int
+		batch    []int
+	}
+	receivedCh := make(chan received)
+	wg := &sync.WaitGroup{}
+
+	// queue the consumers up in order
+	for c := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batcher.ConsumeWithCooldown(ctx, nil) {
+				receivedCh <- received{c, batch}
+			}
+		}()
+		waitForConsumers(batcher, c+1)
+	}
+
+	for i := range 2 * consumers {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		got := <-receivedCh
+		if got.consumer != i%consumers || len(got.batch) != 1 || got.batch[0] != i {
+			t.Fatalf("expected item %d on consumer %d, got %v on %d", i, i%consumers, got.batch, got.consumer)
+		}
+		waitForConsumers(batcher, consumers)
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func TestBatcher_MultipleConsumers_Cancel(t *testing.T) {
+	batcher := NewBatcher[int](nil)
+
+	canceledCtx, cancelFirst := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		for range batcher.ConsumeWithCooldown(canceledCtx, nil) {
+			t.Error("expected canceled consumer to get nothing")
+		}
+	}()
+	waitForConsumers(batcher, 1)
+
+	consumed := make(chan []int)
+	go func() {
+		for batch := range batcher.ConsumeWithCooldown(ctx, nil) {
+			consumed <- batch
+		}
+	}()
+	waitForConsumers(batcher, 2)
+
+	// the other consumer stays asleep
+	cancelFirst()
+	<-firstDone
+
+	if err := batcher.Add(1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if batch := <-consumed; len(batch) != 1 {
+		t.Fatalf("expected the item on the second consumer, got %v", batch)
+	}
+}
+
+func TestBatcher_MultipleConsumers_EachItemOnce(t *testing.T) {
+	items := 2000
+	batcher := NewBatcher[int](nil, WithMaxBatchSize(7))
+
+	mu := &sync.Mutex{}
+	seen := map[int]int{}
+	wg := &sync.WaitGroup{}
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batcher.ConsumeWithCooldown(context.Background(), nil) {
+				mu.Lock()
+				for _, item := range batch {
+					seen[item]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	adders := &sync.WaitGroup{}
+	for a := range 4 {
+		adders.Add(1)
+		go func() {
+			defer adders.Done()
+			for i := a; i < items; i += 4 {
+				if err := batcher.Add(i); err != nil {
+					t.Errorf("unexpected err: %v", err)
+				}
+			}
+		}()
+	}
+	adders.Wait()
+
+	if err := batcher.Drain(context.Background()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	wg.Wait()
+
+	if len(seen) != items {
+		t.Fatalf("expected %d items, got %d", items, len(seen))
+	}
+	for item, n := range seen {
+		if n != 1 {
+			t.Fatalf("expected item %d once, got %d times", item, n)
+		}
+	}
+}
+
+// Waits until n consumers are queued up for a batch.
+func waitForConsumers[T any](batcher *BatcherTyped[T], n int) {
+	for {
+		batcher.mu.Lock()
+		waiting := len(batcher.waiters)
+		batcher.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		runtime.Gosched()
 	}
 }
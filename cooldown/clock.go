@@ -0,0 +1,91 @@
+package cooldown
+
+import (
+	"context"
+	"time"
+)
+
+// Source of time for the [Cooldown] implementations. Defaults to [RealClock];
+// tests use [FakeClock] instead, so that they can step through cooldowns
+// without sleeping and without asserting on how long a sleep took.
+type Clock interface {
+	Now() time.Time
+	// Returns a timer, which fires once, after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer of a [Clock]. See [time.Timer].
+type Timer interface {
+	C() <-chan time.Time
+	// Stops the timer. Returns false, if it has already fired or been stopped.
+	Stop() bool
+}
+
+// [Clock] backed by package time.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+
+func (t realTimer) Stop() bool { return t.t.Stop() }
+
+// Waits for d on clock. The only possible error is ctx.Err().
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// Returns a copy of ctx, which is canceled, once ch is closed.
+func withCancelOn(ctx context.Context, ch <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-ch:
+			cancel()
+		}
+	}()
+	return ctx, cancel
+}
+
+// Returns a copy of ctx, which is canceled, once d passes on clock.
+func withClockTimeout(
+	ctx context.Context,
+	clock Clock,
+	d time.Duration,
+) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if d <= 0 {
+		cancel()
+		return ctx, cancel
+	}
+
+	timer := clock.NewTimer(d)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C():
+			cancel()
+		}
+	}()
+	return ctx, cancel
+}
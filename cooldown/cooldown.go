@@ -6,8 +6,9 @@ import "context"
 // "cooling" before next usage.
 type Cooldown interface {
 	// If not in cooldown, returns immediately, and starts the cooldown.
-	// If in cooldown, waits until cooled and returns nil. Next delay will be
-	// doubled, but not longer then maxDelay.
+	// If in cooldown, waits until cooled and returns nil. How long the cooldown
+	// is, and how it changes after each hit, depends on the implementation and
+	// its [Option]s.
 	// The only possible error is ctx.Err().
 	Hit(ctx context.Context) error
 }
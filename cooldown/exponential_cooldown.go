@@ -6,20 +6,28 @@ import (
 	"time"
 )
 
+// [Cooldown], which delay grows with each hit made during a cooldown (doubles,
+// unless [WithGrowthFactor] is given), and resets to initialDelay, once a
+// cooldown passes without hits.
 type ExponentialCooldown struct {
 	initialDelay time.Duration
 	maxDelay     time.Duration
+	opts         options
 	mu           *sync.Mutex
 
 	// mutable:
 
 	lastHit           time.Time
 	nextCooldownDelay time.Duration
+	// nextCooldownDelay with the jitter applied
+	jitteredDelay time.Duration
 }
 
+// Accepts [WithClock], [WithJitter], [WithRand] and [WithGrowthFactor].
 func NewExponentialCooldown(
 	initialDelay time.Duration,
 	maxDelay time.Duration,
+	opts ...Option,
 ) *ExponentialCooldown {
 	if initialDelay < time.Nanosecond {
 		panic("expected initialDelay to be positive")
@@ -31,6 +39,7 @@ func NewExponentialCooldown(
 	return &ExponentialCooldown{
 		initialDelay:      initialDelay,
 		maxDelay:          maxDelay,
+		opts:              newOptions(opts),
 		mu:                &sync.Mutex{},
 		nextCooldownDelay: initialDelay,
 	}
@@ -50,23 +59,27 @@ func (cd *ExponentialCooldown) Hit(ctx context.Context) error {
 		return err
 	}
 
-	sinceLastHit := time.Since(cd.lastHit)
+	clock := cd.opts.clock
+	sinceLastHit := clock.Now().Sub(cd.lastHit)
 
 	if sinceLastHit >= cd.nextCooldownDelay {
 		// cooldown has passed by itself - resetting the delay
-		cd.lastHit = time.Now()
-		cd.nextCooldownDelay = cd.initialDelay
+		cd.start(clock.Now(), cd.initialDelay)
 		return nil
 	}
 
-	// inside a cooldown
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(cd.nextCooldownDelay - sinceLastHit):
-		// cooldown has passed just now - doubling the delay
-		cd.lastHit = time.Now()
-		cd.nextCooldownDelay = min(cd.nextCooldownDelay*2, cd.maxDelay)
-		return nil
+	// inside a cooldown, which jitter may have already finished
+	if err := sleep(ctx, clock, cd.jitteredDelay-sinceLastHit); err != nil {
+		return err
 	}
+
+	// cooldown has passed just now - growing the delay
+	cd.start(clock.Now(), cd.opts.grown(cd.nextCooldownDelay, cd.maxDelay))
+	return nil
+}
+
+func (cd *ExponentialCooldown) start(now time.Time, delay time.Duration) {
+	cd.lastHit = now
+	cd.nextCooldownDelay = delay
+	cd.jitteredDelay = cd.opts.jittered(delay)
 }
@@ -2,6 +2,8 @@ package cooldown
 
 import (
 	"context"
+	"math/rand/v2"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -83,3 +85,102 @@ func TestExponentialCooldown_Hit_ContextCanceled(t *testing.T) {
 		}
 	}
 }
+
+func TestExponentialCooldown_Hit_FakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	cd := NewExponentialCooldown(
+		100*time.Millisecond,
+		time.Second,
+		WithClock(clock),
+		WithGrowthFactor(3),
+	)
+
+	expected := []time.Duration{
+		0,
+		100 * time.Millisecond,
+		300 * time.Millisecond,
+		900 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, want := range expected {
+		if got := hitDelay(t, clock, cd); got != want {
+			t.Errorf("hit %d: expected delay %v, got %v", i, want, got)
+		}
+	}
+
+	// cooldown passes without hits
+	clock.Advance(time.Second)
+	if got := hitDelay(t, clock, cd); got != 0 {
+		t.Errorf("expected delay to reset, got %v", got)
+	}
+	if got := hitDelay(t, clock, cd); got != 100*time.Millisecond {
+		t.Errorf("expected initial delay after reset, got %v", got)
+	}
+}
+
+func TestExponentialCooldown_Hit_Jitter(t *testing.T) {
+	delay := 100 * time.Millisecond
+
+	for _, tc := range []struct {
+		name     string
+		jitter   Jitter
+		min, max time.Duration
+	}{
+		{"full", FullJitter, 0, delay},
+		{"equal", EqualJitter, delay / 2, delay},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := NewFakeClock(time.Unix(0, 0))
+			cd := NewExponentialCooldown(
+				delay,
+				delay,
+				WithClock(clock),
+				WithJitter(tc.jitter),
+				WithRand(rand.New(rand.NewPCG(1, 2))),
+			)
+
+			hitDelay(t, clock, cd)
+
+			distinct := map[time.Duration]bool{}
+			for range 20 {
+				got := hitDelay(t, clock, cd)
+				if got < tc.min || got >= tc.max {
+					t.Errorf("expected delay in [%v, %v), got %v", tc.min, tc.max, got)
+				}
+				distinct[got] = true
+			}
+			if len(distinct) < 2 {
+				t.Errorf("expected delays to be spread, got %v", distinct)
+			}
+		})
+	}
+}
+
+// Hits cd, advancing clock until the hit returns. Returns how much the clock
+// has been advanced.
+func hitDelay(t *testing.T, clock *FakeClock, cd Cooldown) time.Duration {
+	t.Helper()
+
+	start := clock.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- cd.Hit(context.Background())
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("expected nil err, got %v", err)
+			}
+			return clock.Now().Sub(start)
+		default:
+		}
+
+		if deadline, ok := clock.NextDeadline(); ok {
+			clock.Advance(deadline.Sub(clock.Now()))
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,129 @@
+package cooldown
+
+import (
+	"sync"
+	"time"
+)
+
+// [Clock] for tests, which time only moves with [FakeClock.Advance]. Timers
+// fire, once the clock is advanced past their deadline.
+type FakeClock struct {
+	mu     *sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+var _ Clock = (*FakeClock)(nil)
+
+func NewFakeClock(now time.Time) *FakeClock {
+	mu := &sync.Mutex{}
+	return &FakeClock{
+		mu:   mu,
+		cond: sync.NewCond(mu),
+		now:  now,
+	}
+}
+
+// Implements [Clock]
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Implements [Clock]
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		ch:       make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Moves the clock forward by d, firing the timers, which deadline has come.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	clear(c.timers[len(pending):])
+	c.timers = pending
+	c.cond.Broadcast()
+}
+
+// Returns the number of timers, which have not fired or been stopped yet.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Waits until there are at least n timers, which have not fired or been
+// stopped. Allows a test to advance the clock only after the code under test
+// has started waiting.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// Returns the earliest deadline among the pending timers, and false, if there
+// are none.
+func (c *FakeClock) NextDeadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var next time.Time
+	for _, t := range c.timers {
+		if next.IsZero() || t.deadline.Before(next) {
+			next = t.deadline
+		}
+	}
+	return next, len(c.timers) > 0
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
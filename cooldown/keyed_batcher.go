@@ -0,0 +1,103 @@
+package cooldown
+
+// Combines the pending item for a key with the newly added one. See
+// [NewKeyedBatcher].
+type KeyedMerge[V any] func(pending V, newItem V) V
+
+// Latest state of a key, as consumed from [KeyedBatcher].
+type KeyedItem[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// [BatcherTyped], which keeps at most one pending item per key: adding an item
+// for a key, which is already in the batch, merges the two, instead of
+// appending. Suits the "rescan these devices" or "resync these nodes" kind of
+// work, where only the latest state of each key matters.
+//
+// Batches are ordered by the time a key was first added to the batch; merging
+// does not move the key. Use [KeyedItems] to have a batch as a map.
+type KeyedBatcher[K comparable, V any] struct {
+	*BatcherTyped[KeyedItem[K, V]]
+}
+
+// Creates new [*KeyedBatcher] with merge, which combines items of the same key.
+// If merge is nil, the newly added item replaces the pending one.
+//
+// Accepts the same options as [NewBatcher].
+func NewKeyedBatcher[K comparable, V any](merge KeyedMerge[V], opts ...Option) *KeyedBatcher[K, V] {
+	if merge == nil {
+		merge = func(_ V, newItem V) V {
+			return newItem
+		}
+	}
+
+	return &KeyedBatcher[K, V]{
+		BatcherTyped: newBatcher[KeyedItem[K, V]](&keyedBuffer[K, V]{
+			merge: merge,
+			index: map[K]int{},
+		}, opts),
+	}
+}
+
+// Adds item for key, merging it with the pending item for the same key, if
+// there is one.
+func (b *KeyedBatcher[K, V]) Add(key K, item V) error {
+	return b.BatcherTyped.Add(KeyedItem[K, V]{Key: key, Value: item})
+}
+
+// Returns batch as a map of key to its latest state.
+func KeyedItems[K comparable, V any](batch []KeyedItem[K, V]) map[K]V {
+	res := make(map[K]V, len(batch))
+	for _, item := range batch {
+		res[item.Key] = item.Value
+	}
+	return res
+}
+
+// [batchBuffer] with an item per key
+type keyedBuffer[K comparable, V any] struct {
+	merge KeyedMerge[V]
+	// position of each key in items
+	index map[K]int
+	items []KeyedItem[K, V]
+}
+
+func (buf *keyedBuffer[K, V]) add(newItem KeyedItem[K, V]) {
+	if i, ok := buf.index[newItem.Key]; ok {
+		buf.items[i].Value = buf.merge(buf.items[i].Value, newItem.Value)
+		return
+	}
+	buf.index[newItem.Key] = len(buf.items)
+	buf.items = append(buf.items, newItem)
+}
+
+func (buf *keyedBuffer[K, V]) len() int {
+	return len(buf.items)
+}
+
+func (buf *keyedBuffer[K, V]) grows(newItem KeyedItem[K, V]) bool {
+	_, ok := buf.index[newItem.Key]
+	return !ok
+}
+
+func (buf *keyedBuffer[K, V]) take(n int) []KeyedItem[K, V] {
+	if n <= 0 || n > len(buf.items) {
+		n = len(buf.items)
+	}
+
+	res := buf.items[:n:n]
+	buf.items = append(make([]KeyedItem[K, V], 0, len(buf.items)), buf.items[n:]...)
+
+	clear(buf.index)
+	for i, item := range buf.items {
+		buf.index[item.Key] = i
+	}
+	return res
+}
+
+func (buf *keyedBuffer[K, V]) dropOldest() {
+	if len(buf.items) > 0 {
+		buf.take(1)
+	}
+}
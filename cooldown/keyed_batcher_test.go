@@ -0,0 +1,119 @@
+package cooldown
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+)
+
+func TestKeyedBatcher_Merge(t *testing.T) {
+	batcher := NewKeyedBatcher[string](func(pending int, newItem int) int {
+		return pending + newItem
+	})
+
+	for _, add := range []struct {
+		key  string
+		item int
+	}{
+		{"b", 1},
+		{"a", 10},
+		{"b", 2},
+		{"c", 100},
+		{"a", 20},
+	} {
+		if err := batcher.Add(add.key, add.item); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next, stop := iter.Pull(batcher.ConsumeWithCooldown(ctx, nil))
+	defer stop()
+
+	batch, ok := next()
+	if !ok {
+		t.Fatal("expected a batch, got nothing")
+	}
+
+	expected := []KeyedItem[string, int]{{"b", 3}, {"a", 30}, {"c", 100}}
+	if len(batch) != len(expected) {
+		t.Fatalf("expected batch %v, got %v", expected, batch)
+	}
+	for i := range expected {
+		if batch[i] != expected[i] {
+			t.Fatalf("expected batch %v, got %v", expected, batch)
+		}
+	}
+
+	// keys start over in the next batch
+	if err := batcher.Add("a", 1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	batch, ok = next()
+	if !ok {
+		t.Fatal("expected a batch, got nothing")
+	}
+	if items := KeyedItems(batch); len(items) != 1 || items["a"] != 1 {
+		t.Fatalf("expected only a=1, got %v", items)
+	}
+}
+
+func TestKeyedBatcher_ReplaceByDefault(t *testing.T) {
+	batcher := NewKeyedBatcher[int, string](nil)
+
+	for _, item := range []string{"first", "second", "latest"} {
+		if err := batcher.Add(1, item); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for batch := range batcher.ConsumeWithCooldown(ctx, nil) {
+		if len(batch) != 1 || batch[0].Value != "latest" {
+			t.Fatalf("expected the latest item, got %v", batch)
+		}
+		return
+	}
+}
+
+func TestKeyedBatcher_MaxBuffered(t *testing.T) {
+	batcher := NewKeyedBatcher[string, int](nil, WithMaxBuffered(1, OverflowError))
+
+	if err := batcher.Add("a", 1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	// merging does not grow the buffer
+	if err := batcher.Add("a", 2); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := batcher.Add("b", 1); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("expected '%v', got '%v'", ErrBufferFull, err)
+	}
+}
+
+func TestKeyedBatcher_MaxBatchSize(t *testing.T) {
+	batcher := NewKeyedBatcher[int, int](nil, WithMaxBatchSize(2))
+
+	for i := range 3 {
+		if err := batcher.Add(i, i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	first := consumeOne(t, batcher.BatcherTyped)
+
+	// the key left in the buffer still merges
+	if err := batcher.Add(2, 20); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	second := consumeOne(t, batcher.BatcherTyped)
+
+	if len(first) != 2 || len(second) != 1 || second[0] != (KeyedItem[int, int]{2, 20}) {
+		t.Fatalf("expected [0 1] and [2:20], got %v and %v", first, second)
+	}
+}
@@ -0,0 +1,149 @@
+package cooldown
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus collector of [BatcherTyped] events. Create one per process,
+// register it, and give each batcher an [Observer] of its own name:
+//
+//	m := cooldown.NewBatcherMetrics()
+//	metrics.Registry.MustRegister(m)
+//	batcher := cooldown.NewBatcher[T](nil, cooldown.WithObserver(m.For("rescan")))
+//
+// Exported series, all labelled batcher:
+//
+//   - sds_batcher_buffered_items: number of pending items;
+//   - sds_batcher_batch_size: histogram of the sizes of the taken batches;
+//   - sds_batcher_batch_age_seconds: histogram of how long the oldest item of
+//     a batch has been pending, when the batch was taken;
+//   - sds_batcher_throttled_seconds_total: time consumers spent waiting for
+//     the cooldown;
+//   - sds_batcher_dropped_items_total: items dropped, also labelled reason
+//     (see [DropReason]).
+type BatcherMetrics struct {
+	buffered  *prometheus.GaugeVec
+	batchSize *prometheus.HistogramVec
+	batchAge  *prometheus.HistogramVec
+	throttled *prometheus.CounterVec
+	dropped   *prometheus.CounterVec
+}
+
+var _ prometheus.Collector = (*BatcherMetrics)(nil)
+
+// Creates unregistered [*BatcherMetrics].
+func NewBatcherMetrics() *BatcherMetrics {
+	return &BatcherMetrics{
+		buffered: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sds_batcher_buffered_items",
+			Help: "Number of items pending in a batcher.",
+		}, []string{"batcher"}),
+		batchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "sds_batcher_batch_size",
+			Help: "Number of items in the batches taken from a batcher.",
+			// from a single item to thousands of them
+			Buckets: prometheus.ExponentialBuckets(1, 4, 7),
+		}, []string{"batcher"}),
+		batchAge: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "sds_batcher_batch_age_seconds",
+			Help: "How long the oldest item of a batch had been pending, when the batch was taken.",
+			// from a millisecond to an hour
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 12),
+		}, []string{"batcher"}),
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sds_batcher_throttled_seconds_total",
+			Help: "Time consumers of a batcher spent waiting for the cooldown.",
+		}, []string{"batcher"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sds_batcher_dropped_items_total",
+			Help: "Number of items, which left a batcher without reaching a consumer.",
+		}, []string{"batcher", "reason"}),
+	}
+}
+
+// Implements [prometheus.Collector]
+func (m *BatcherMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.buffered.Describe(ch)
+	m.batchSize.Describe(ch)
+	m.batchAge.Describe(ch)
+	m.throttled.Describe(ch)
+	m.dropped.Describe(ch)
+}
+
+// Implements [prometheus.Collector]
+func (m *BatcherMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.buffered.Collect(ch)
+	m.batchSize.Collect(ch)
+	m.batchAge.Collect(ch)
+	m.throttled.Collect(ch)
+	m.dropped.Collect(ch)
+}
+
+// Returns [Observer], which exports the events of the batcher of name. Batchers
+// of the same name add up, sds_batcher_buffered_items included, which is their
+// total depth: a [PartitionedBatcher] reports as one batcher, if each of its
+// workers is given an Observer of the same name. Since an Observer tracks the
+// depth of the batcher it is given to, call For once per batcher, rather than
+// sharing its result.
+func (m *BatcherMetrics) For(name string) Observer {
+	return &batcherMetricsObserver{
+		buffered:  m.buffered.WithLabelValues(name),
+		batchSize: m.batchSize.WithLabelValues(name),
+		batchAge:  m.batchAge.WithLabelValues(name),
+		throttled: m.throttled.WithLabelValues(name),
+		dropped:   m.dropped.MustCurryWith(prometheus.Labels{"batcher": name}),
+	}
+}
+
+// Deletes the series of the batcher of name. Call it, once the batcher is no
+// longer used, or its last values stay exported for as long as the process
+// lives.
+func (m *BatcherMetrics) Forget(name string) {
+	labels := prometheus.Labels{"batcher": name}
+	m.buffered.DeletePartialMatch(labels)
+	m.batchSize.DeletePartialMatch(labels)
+	m.batchAge.DeletePartialMatch(labels)
+	m.throttled.DeletePartialMatch(labels)
+	m.dropped.DeletePartialMatch(labels)
+}
+
+type batcherMetricsObserver struct {
+	// Guards depth: the gauge is shared by the batchers of a name, so each
+	// moves it by the change of its own depth rather than setting it.
+	mu        sync.Mutex
+	depth     int
+	buffered  prometheus.Gauge
+	batchSize prometheus.Observer
+	batchAge  prometheus.Observer
+	throttled prometheus.Counter
+	dropped   *prometheus.CounterVec
+}
+
+func (o *batcherMetricsObserver) ItemAdded(buffered int) {
+	o.setBuffered(buffered)
+}
+
+func (o *batcherMetricsObserver) BatchTaken(size int, age time.Duration, buffered int) {
+	o.batchSize.Observe(float64(size))
+	o.batchAge.Observe(age.Seconds())
+	o.setBuffered(buffered)
+}
+
+func (o *batcherMetricsObserver) CooldownWaited(d time.Duration) {
+	o.throttled.Add(d.Seconds())
+}
+
+func (o *batcherMetricsObserver) ItemsDropped(n int, reason DropReason, buffered int) {
+	o.dropped.WithLabelValues(string(reason)).Add(float64(n))
+	o.setBuffered(buffered)
+}
+
+func (o *batcherMetricsObserver) setBuffered(buffered int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buffered.Add(float64(buffered - o.depth))
+	o.depth = buffered
+}
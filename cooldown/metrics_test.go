@@ -0,0 +1,97 @@
+package cooldown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBatcherMetrics(t *testing.T) {
+	m := NewBatcherMetrics()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+
+	rescan := m.For("rescan")
+	rescan.ItemAdded(1)
+	rescan.ItemAdded(2)
+	rescan.ItemAdded(3)
+	rescan.BatchTaken(3, 2*time.Second, 0)
+	rescan.CooldownWaited(1500 * time.Millisecond)
+	rescan.CooldownWaited(500 * time.Millisecond)
+	rescan.ItemsDropped(2, DropOverflow, 1)
+	m.For("resync").ItemAdded(5)
+
+	if n := testutil.CollectAndCount(m, "sds_batcher_buffered_items"); n != 2 {
+		t.Fatalf("expected a buffer depth per batcher, got %d", n)
+	}
+	for name, want := range map[string]float64{"rescan": 1, "resync": 5} {
+		if got := testutil.ToFloat64(m.buffered.WithLabelValues(name)); got != want {
+			t.Errorf("expected %s to have buffered %v, got %v", name, want, got)
+		}
+	}
+
+	// series of a batcher are there from the start
+	if err := testutil.CollectAndCompare(m, strings.NewReader(`
+# HELP sds_batcher_batch_size Number of items in the batches taken from a batcher.
+# TYPE sds_batcher_batch_size histogram
+sds_batcher_batch_size_bucket{batcher="rescan",le="1"} 0
+sds_batcher_batch_size_bucket{batcher="rescan",le="4"} 1
+sds_batcher_batch_size_bucket{batcher="rescan",le="16"} 1
+sds_batcher_batch_size_bucket{batcher="rescan",le="64"} 1
+sds_batcher_batch_size_bucket{batcher="rescan",le="256"} 1
+sds_batcher_batch_size_bucket{batcher="rescan",le="1024"} 1
+sds_batcher_batch_size_bucket{batcher="rescan",le="4096"} 1
+sds_batcher_batch_size_bucket{batcher="rescan",le="+Inf"} 1
+sds_batcher_batch_size_sum{batcher="rescan"} 3
+sds_batcher_batch_size_count{batcher="rescan"} 1
+sds_batcher_batch_size_bucket{batcher="resync",le="1"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="4"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="16"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="64"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="256"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="1024"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="4096"} 0
+sds_batcher_batch_size_bucket{batcher="resync",le="+Inf"} 0
+sds_batcher_batch_size_sum{batcher="resync"} 0
+sds_batcher_batch_size_count{batcher="resync"} 0
+`), "sds_batcher_batch_size"); err != nil {
+		t.Errorf("expected one batch of 3: %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.throttled.WithLabelValues("rescan")); got != 2 {
+		t.Errorf("expected 2s throttled, got %v", got)
+	}
+
+	if n := testutil.CollectAndCount(m, "sds_batcher_dropped_items_total"); n != 1 {
+		t.Errorf("expected drops of one reason only, got %d series", n)
+	}
+	if got := testutil.ToFloat64(m.dropped.WithLabelValues("rescan", string(DropOverflow))); got != 2 {
+		t.Errorf("expected 2 items dropped on overflow, got %v", got)
+	}
+
+	m.Forget("rescan")
+	if n := testutil.CollectAndCount(m, "sds_batcher_buffered_items"); n != 1 {
+		t.Errorf("expected only resync left, got %d series", n)
+	}
+}
+
+// The workers of a partitioned batcher share a name, and the depth exported is
+// that of all of them.
+func TestBatcherMetricsAddUpTheDepthOfBatchersOfOneName(t *testing.T) {
+	m := NewBatcherMetrics()
+	batcher := NewPartitionedBatcher(2, func(item int) int { return item }, func() *BatcherTyped[int] {
+		return NewBatcher[int](nil, WithObserver(m.For("rescan")))
+	})
+
+	for i := range 4 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if got := testutil.ToFloat64(m.buffered.WithLabelValues("rescan")); got != 4 {
+		t.Errorf("expected 4 items buffered across the workers, got %v", got)
+	}
+}
@@ -0,0 +1,52 @@
+package cooldown
+
+import "time"
+
+// Why items left a [BatcherTyped] without reaching a consumer.
+type DropReason string
+
+const (
+	// Dropped to make room for a new item, see [OverflowDropOldest].
+	DropOverflow DropReason = "overflow"
+	// Not added, since the buffer was full, see [OverflowError].
+	DropRejected DropReason = "rejected"
+	// Taken by a consumer, which context was canceled during the cooldown.
+	DropCanceled DropReason = "canceled"
+)
+
+// Receives the events of a [BatcherTyped], see [WithObserver]. The methods are
+// called synchronously, some of them under the lock of the batcher, so they
+// should return quickly, and must not call the batcher.
+//
+// buffered is the number of pending items after the event.
+type Observer interface {
+	ItemAdded(buffered int)
+	// A consumer has taken size items, the oldest of which has been pending
+	// for age.
+	BatchTaken(size int, age time.Duration, buffered int)
+	// A consumer has waited d for the cooldown before getting a batch.
+	CooldownWaited(d time.Duration)
+	ItemsDropped(n int, reason DropReason, buffered int)
+}
+
+// [Observer], which ignores all the events. Embed it to implement only some of
+// them.
+type NopObserver struct{}
+
+var _ Observer = NopObserver{}
+
+func (NopObserver) ItemAdded(int)                      {}
+func (NopObserver) BatchTaken(int, time.Duration, int) {}
+func (NopObserver) CooldownWaited(time.Duration)       {}
+func (NopObserver) ItemsDropped(int, DropReason, int)  {}
+
+// Makes a [BatcherTyped] report its events to observer. See [BatcherMetrics]
+// for one, which exports them to Prometheus.
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		if observer == nil {
+			panic("expected observer to be non-nil")
+		}
+		o.observer = observer
+	}
+}
@@ -0,0 +1,128 @@
+package cooldown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Records events as strings.
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(format string, args ...any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+}
+
+func (o *recordingObserver) ItemAdded(buffered int) {
+	o.record("added buffered=%d", buffered)
+}
+
+func (o *recordingObserver) BatchTaken(size int, age time.Duration, buffered int) {
+	o.record("taken size=%d age=%v buffered=%d", size, age, buffered)
+}
+
+func (o *recordingObserver) CooldownWaited(d time.Duration) {
+	o.record("waited %v", d)
+}
+
+func (o *recordingObserver) ItemsDropped(n int, reason DropReason, buffered int) {
+	o.record("dropped n=%d reason=%s buffered=%d", n, reason, buffered)
+}
+
+func (o *recordingObserver) take() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	events := o.events
+	o.events = nil
+	return events
+}
+
+func TestBatcher_Observer(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	observer := &recordingObserver{}
+	batcher := NewBatcher[int](
+		nil,
+		WithClock(clock),
+		WithObserver(observer),
+		WithMaxBuffered(2, OverflowDropOldest),
+	)
+	cooldown := NewExponentialCooldown(time.Second, time.Second, WithClock(clock))
+
+	for i := range 3 {
+		if err := batcher.Add(i); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+	clock.Advance(time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumed := make(chan []int)
+	go func() {
+		defer close(consumed)
+		for batch := range batcher.ConsumeWithCooldown(ctx, cooldown) {
+			consumed <- batch
+		}
+	}()
+
+	<-consumed
+
+	if err := batcher.Add(3); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-consumed
+
+	// canceled during the cooldown
+	if err := batcher.Add(4); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	clock.BlockUntil(1)
+	cancel()
+	<-consumed
+
+	expected := []string{
+		"added buffered=1",
+		"added buffered=2",
+		"dropped n=1 reason=overflow buffered=1",
+		"added buffered=2",
+		"taken size=2 age=1s buffered=0",
+		"waited 0s",
+		"added buffered=1",
+		"taken size=1 age=0s buffered=0",
+		"waited 1s",
+		"added buffered=1",
+		"taken size=1 age=0s buffered=0",
+		"waited 0s",
+		"dropped n=1 reason=canceled buffered=0",
+	}
+	if events := observer.take(); !slices.Equal(events, expected) {
+		t.Fatalf("expected events\n%q\ngot\n%q", expected, events)
+	}
+}
+
+func TestBatcher_Observer_Rejected(t *testing.T) {
+	observer := &recordingObserver{}
+	batcher := NewBatcher[int](nil, WithObserver(observer), WithMaxBuffered(1, OverflowError))
+
+	if err := batcher.Add(1); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := batcher.Add(2); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("expected '%v', got '%v'", ErrBufferFull, err)
+	}
+
+	expected := []string{"added buffered=1", "dropped n=1 reason=rejected buffered=1"}
+	if events := observer.take(); !slices.Equal(events, expected) {
+		t.Fatalf("expected events %q, got %q", expected, events)
+	}
+}
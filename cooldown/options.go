@@ -0,0 +1,193 @@
+package cooldown
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Spreads the delays of a [Cooldown], so that the consumers, which hit their
+// cooldowns at the same time, do not wake up at the same time. See
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+type Jitter int
+
+const (
+	// Waits exactly the delay.
+	NoJitter Jitter = iota
+	// Waits a random duration in [0, delay).
+	FullJitter
+	// Waits delay/2 plus a random duration in [0, delay/2).
+	EqualJitter
+)
+
+// What [BatcherTyped.Add] does, when the buffer has reached the limit of
+// [WithMaxBuffered].
+type Overflow int
+
+const (
+	// Waits until a consumer takes items out of the buffer.
+	OverflowBlock Overflow = iota
+	// Drops the oldest pending item to make room for the new one.
+	OverflowDropOldest
+	// Returns [ErrBufferFull].
+	OverflowError
+)
+
+// Configures a [Cooldown] implementation or a [BatcherTyped]. Options, which
+// do not make sense for an implementation, are ignored by it. [WithClock],
+// [WithJitter] and [WithRand] apply to every Cooldown implementation.
+type Option func(*options)
+
+type options struct {
+	clock        Clock
+	jitter       Jitter
+	rand         *rand.Rand
+	growthFactor float64
+
+	// batcher:
+
+	maxBatchSize int
+	maxItemAge   time.Duration
+	maxBuffered  int
+	overflow     Overflow
+	observer     Observer
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		clock:        RealClock,
+		growthFactor: 2,
+		observer:     NopObserver{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Makes the cooldown measure time with clock. Default is [RealClock].
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock == nil {
+			panic("expected clock to be non-nil")
+		}
+		o.clock = clock
+	}
+}
+
+// Makes the cooldown spread its delays with jitter. Default is [NoJitter].
+//
+// A rate limiting cooldown — [TokenBucketCooldown], [SlidingWindowCooldown] —
+// only ever lengthens a wait by the jitter: its limit is a hard one. It waits
+// the delay plus what the jitter would have taken off it, so FullJitter waits
+// up to twice the delay, and EqualJitter up to one and a half of it.
+func WithJitter(jitter Jitter) Option {
+	return func(o *options) {
+		if jitter < NoJitter || jitter > EqualJitter {
+			panic("unknown jitter")
+		}
+		o.jitter = jitter
+	}
+}
+
+// Makes the jitter random with r, instead of the global source of package
+// rand, for tests to repeat. r is only used under the lock of the cooldown.
+func WithRand(r *rand.Rand) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// Makes the delay of [ExponentialCooldown] grow factor times after each
+// cooldown, instead of doubling.
+func WithGrowthFactor(factor float64) Option {
+	return func(o *options) {
+		if factor < 1 {
+			panic("expected growth factor to be greater or equal to 1")
+		}
+		o.growthFactor = factor
+	}
+}
+
+// Limits batches of a [BatcherTyped] to n items. The rest of the buffer is left
+// for the next batch, which is still subject to the cooldown.
+func WithMaxBatchSize(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			panic("expected max batch size to be positive")
+		}
+		o.maxBatchSize = n
+	}
+}
+
+// Makes a [BatcherTyped] hand a batch to the consumer without waiting for the
+// rest of the cooldown, once its oldest item has been pending for age.
+//
+// Age is measured since the buffer became non-empty, since batchAppend of
+// [NewBatcher] is free to reorder the items.
+func WithMaxItemAge(age time.Duration) Option {
+	return func(o *options) {
+		if age < time.Nanosecond {
+			panic("expected max item age to be positive")
+		}
+		o.maxItemAge = age
+	}
+}
+
+// Limits the buffer of a [BatcherTyped] to n pending items, applying overflow
+// to an item, which would exceed it. Items merged into a pending one by
+// [KeyedBatcher] do not count, while for [NewBatcher] any item does, since
+// batchAppend is opaque.
+func WithMaxBuffered(n int, overflow Overflow) Option {
+	return func(o *options) {
+		if n < 1 {
+			panic("expected max buffered to be positive")
+		}
+		if overflow < OverflowBlock || overflow > OverflowError {
+			panic("unknown overflow")
+		}
+		o.maxBuffered = n
+		o.overflow = overflow
+	}
+}
+
+// Returns the delay to wait instead of d, according to the jitter.
+func (o *options) jittered(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	switch o.jitter {
+	case FullJitter:
+		return o.randN(d)
+	case EqualJitter:
+		half := d / 2
+		return d - half + o.randN(half)
+	default:
+		return d
+	}
+}
+
+// Returns the delay to wait instead of d, according to the jitter, but never
+// shorter than d.
+func (o *options) jitteredUp(d time.Duration) time.Duration {
+	return 2*d - o.jittered(d)
+}
+
+// Returns a random duration in [0, n).
+func (o *options) randN(n time.Duration) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	if o.rand != nil {
+		return time.Duration(o.rand.Int64N(int64(n)))
+	}
+	return time.Duration(rand.Int64N(int64(n)))
+}
+
+// Returns d grown by the growth factor, but not longer then limit.
+func (o *options) grown(d time.Duration, limit time.Duration) time.Duration {
+	next := float64(d) * o.growthFactor
+	if next >= float64(limit) {
+		return limit
+	}
+	return time.Duration(next)
+}
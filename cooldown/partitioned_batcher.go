@@ -0,0 +1,90 @@
+package cooldown
+
+import (
+	"context"
+	"hash/maphash"
+)
+
+// Set of [BatcherTyped], one per worker, which routes each item by partition
+// function, so that the items of the same partition always reach the same
+// worker. Each worker consumes its own batcher with
+// [BatcherTyped.ConsumeWithCooldown].
+type PartitionedBatcher[T any] struct {
+	partition func(item T) int
+	batchers  []*BatcherTyped[T]
+}
+
+// Creates new [*PartitionedBatcher] for workers workers. Item goes to the
+// worker partition(item) modulo workers. newBatcher creates the batcher of
+// each worker, [NewBatcher] without batchAppend if nil.
+func NewPartitionedBatcher[T any](
+	workers int,
+	partition func(item T) int,
+	newBatcher func() *BatcherTyped[T],
+) *PartitionedBatcher[T] {
+	if workers < 1 {
+		panic("expected workers to be positive")
+	}
+	if partition == nil {
+		panic("expected partition to be non-nil")
+	}
+	if newBatcher == nil {
+		newBatcher = func() *BatcherTyped[T] {
+			return NewBatcher[T](nil)
+		}
+	}
+
+	batchers := make([]*BatcherTyped[T], workers)
+	for i := range batchers {
+		batchers[i] = newBatcher()
+	}
+	return &PartitionedBatcher[T]{
+		partition: partition,
+		batchers:  batchers,
+	}
+}
+
+// Partition function for [KeyedBatcher] items, which spreads keys evenly.
+func PartitionByKey[K comparable, V any]() func(item KeyedItem[K, V]) int {
+	seed := maphash.MakeSeed()
+	return func(item KeyedItem[K, V]) int {
+		return int(maphash.Comparable(seed, item.Key) >> 1)
+	}
+}
+
+// Adds newItem to the batcher of its worker. See [BatcherTyped.Add].
+func (b *PartitionedBatcher[T]) Add(newItem T) error {
+	return b.Worker(b.partition(newItem)).Add(newItem)
+}
+
+// Returns the batcher of the worker i modulo the number of workers.
+func (b *PartitionedBatcher[T]) Worker(i int) *BatcherTyped[T] {
+	i %= len(b.batchers)
+	if i < 0 {
+		i += len(b.batchers)
+	}
+	return b.batchers[i]
+}
+
+// Returns the number of workers.
+func (b *PartitionedBatcher[T]) Workers() int {
+	return len(b.batchers)
+}
+
+// Closes the batchers of all the workers. See [BatcherTyped.Close].
+func (b *PartitionedBatcher[T]) Close() {
+	for _, batcher := range b.batchers {
+		batcher.Close()
+	}
+}
+
+// Drains the batchers of all the workers. See [BatcherTyped.Drain].
+func (b *PartitionedBatcher[T]) Drain(ctx context.Context) error {
+	b.Close()
+	for _, batcher := range b.batchers {
+		if err := batcher.Drain(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
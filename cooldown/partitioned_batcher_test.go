@@ -0,0 +1,90 @@
+package cooldown
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestPartitionedBatcher_SameKeySameWorker(t *testing.T) {
+	workers := 4
+	batcher := NewPartitionedBatcher(
+		workers,
+		PartitionByKey[string, int](),
+		func() *BatcherTyped[KeyedItem[string, int]] {
+			return NewKeyedBatcher[string, int](nil).BatcherTyped
+		},
+	)
+
+	mu := &sync.Mutex{}
+	workerOf := map[string]int{}
+	wg := &sync.WaitGroup{}
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batcher.Worker(w).ConsumeWithCooldown(context.Background(), nil) {
+				mu.Lock()
+				for _, item := range batch {
+					if other, ok := workerOf[item.Key]; ok && other != w {
+						t.Errorf("key %s reached workers %d and %d", item.Key, other, w)
+					}
+					workerOf[item.Key] = w
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	adders := &sync.WaitGroup{}
+	for a := range 4 {
+		adders.Add(1)
+		go func() {
+			defer adders.Done()
+			for i := range 500 {
+				key := fmt.Sprintf("key-%d", (i+a)%50)
+				if err := batcher.Add(KeyedItem[string, int]{Key: key, Value: i}); err != nil {
+					t.Errorf("unexpected err: %v", err)
+				}
+			}
+		}()
+	}
+	adders.Wait()
+
+	if err := batcher.Drain(context.Background()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	wg.Wait()
+
+	if len(workerOf) != 50 {
+		t.Fatalf("expected 50 keys consumed, got %d", len(workerOf))
+	}
+	used := map[int]bool{}
+	for _, w := range workerOf {
+		used[w] = true
+	}
+	if len(used) < 2 {
+		t.Errorf("expected keys spread over workers, got %v", used)
+	}
+}
+
+func TestPartitionedBatcher_Worker(t *testing.T) {
+	batcher := NewPartitionedBatcher(3, func(item int) int { return item }, nil)
+
+	if batcher.Workers() != 3 {
+		t.Fatalf("expected 3 workers, got %d", batcher.Workers())
+	}
+	if batcher.Worker(-1) != batcher.Worker(2) || batcher.Worker(4) != batcher.Worker(1) {
+		t.Fatal("expected worker index to wrap around")
+	}
+
+	if err := batcher.Add(-2); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	batcher.Close()
+
+	if batch := consumeOne(t, batcher.Worker(1)); len(batch) != 1 || batch[0] != -2 {
+		t.Fatalf("expected item on worker 1, got %v", batch)
+	}
+}
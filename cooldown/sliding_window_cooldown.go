@@ -0,0 +1,73 @@
+package cooldown
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// [Cooldown], which allows at most limit hits within any period: a hit waits
+// until the limit-th hit before it is older than period.
+type SlidingWindowCooldown struct {
+	limit  int
+	period time.Duration
+	opts   options
+	mu     *sync.Mutex
+
+	// mutable:
+
+	// times of the last hits, oldest first, at most limit of them
+	hits []time.Time
+}
+
+// Accepts [WithClock], [WithJitter] and [WithRand]. Since limit is a hard one,
+// the jitter only lengthens a wait for the window.
+func NewSlidingWindowCooldown(
+	limit int,
+	period time.Duration,
+	opts ...Option,
+) *SlidingWindowCooldown {
+	if limit < 1 {
+		panic("expected limit to be positive")
+	}
+	if period < time.Nanosecond {
+		panic("expected period to be positive")
+	}
+
+	return &SlidingWindowCooldown{
+		limit:  limit,
+		period: period,
+		opts:   newOptions(opts),
+		mu:     &sync.Mutex{},
+		hits:   make([]time.Time, 0, limit),
+	}
+}
+
+// Implements [Cooldown]
+func (cd *SlidingWindowCooldown) Hit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	// repeating cancelation check, since lock may have taken a long time
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	clock := cd.opts.clock
+
+	if len(cd.hits) == cd.limit {
+		// window is full - waiting for the oldest hit to leave it
+		wait := cd.hits[0].Add(cd.period).Sub(clock.Now())
+		if err := sleep(ctx, clock, cd.opts.jitteredUp(wait)); err != nil {
+			return err
+		}
+		cd.hits = append(cd.hits[:0], cd.hits[1:]...)
+	}
+
+	cd.hits = append(cd.hits, clock.Now())
+	return nil
+}
@@ -0,0 +1,98 @@
+package cooldown
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowCooldown_Hit_Limit(t *testing.T) {
+	period := time.Second
+	clock := NewFakeClock(time.Unix(0, 0))
+	cd := NewSlidingWindowCooldown(3, period, WithClock(clock))
+
+	// hits at 0, 100ms, 200ms
+	for i := range 3 {
+		if got := hitDelay(t, clock, cd); got != 0 {
+			t.Errorf("hit %d: expected no delay within limit, got %v", i, got)
+		}
+		clock.Advance(100 * time.Millisecond)
+	}
+
+	// at 300ms, waiting for the hit at 0 to leave the window
+	if got := hitDelay(t, clock, cd); got != 700*time.Millisecond {
+		t.Errorf("expected delay %v, got %v", 700*time.Millisecond, got)
+	}
+
+	// at 1s, waiting for the hit at 100ms
+	if got := hitDelay(t, clock, cd); got != 100*time.Millisecond {
+		t.Errorf("expected delay %v, got %v", 100*time.Millisecond, got)
+	}
+
+	// window has emptied
+	clock.Advance(period)
+	for i := range 3 {
+		if got := hitDelay(t, clock, cd); got != 0 {
+			t.Errorf("hit %d: expected no delay after the window passed, got %v", i, got)
+		}
+	}
+}
+
+func TestSlidingWindowCooldown_Hit_ContextCanceled(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	cd := NewSlidingWindowCooldown(1, time.Second, WithClock(clock))
+
+	if err := cd.Hit(context.Background()); err != nil {
+		t.Fatalf("expected nil err, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cd.Hit(ctx)
+	}()
+
+	clock.BlockUntil(1)
+	cancel()
+
+	// strict check
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("expected '%v', got '%v'", context.Canceled, err)
+	}
+}
+
+func TestSlidingWindowCooldown_Hit_Jitter(t *testing.T) {
+	period := 100 * time.Millisecond
+
+	for _, tc := range []struct {
+		name     string
+		jitter   Jitter
+		min, max time.Duration
+	}{
+		{"none", NoJitter, period, period},
+		{"full", FullJitter, period, 2 * period},
+		{"equal", EqualJitter, period, period + period/2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 2))
+
+			distinct := map[time.Duration]bool{}
+			for range 20 {
+				clock := NewFakeClock(time.Unix(0, 0))
+				cd := NewSlidingWindowCooldown(1, period, WithClock(clock), WithJitter(tc.jitter), WithRand(r))
+
+				hitDelay(t, clock, cd)
+				// the window is spent: the limit is never jittered away
+				got := hitDelay(t, clock, cd)
+				if got < tc.min || got > tc.max {
+					t.Errorf("expected delay in [%v, %v], got %v", tc.min, tc.max, got)
+				}
+				distinct[got] = true
+			}
+			if tc.jitter != NoJitter && len(distinct) < 2 {
+				t.Errorf("expected delays to be spread, got %v", distinct)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package cooldown
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// [Cooldown], which allows a hit per interval on average, and up to burst hits
+// at once after a pause. It is a token bucket of burst tokens, which gains a
+// token each interval: a hit takes a token, waiting for one if the bucket is
+// empty.
+type TokenBucketCooldown struct {
+	interval time.Duration
+	burst    int
+	opts     options
+	mu       *sync.Mutex
+
+	// mutable:
+
+	// theoretical arrival time of the next hit, if hits came evenly: the
+	// bucket is full, when it is in the past
+	tat time.Time
+}
+
+// Accepts [WithClock], [WithJitter] and [WithRand]. Since rate is a hard
+// limit, the jitter only lengthens a wait for a token.
+func NewTokenBucketCooldown(
+	interval time.Duration,
+	burst int,
+	opts ...Option,
+) *TokenBucketCooldown {
+	if interval < time.Nanosecond {
+		panic("expected interval to be positive")
+	}
+	if burst < 1 {
+		panic("expected burst to be positive")
+	}
+
+	return &TokenBucketCooldown{
+		interval: interval,
+		burst:    burst,
+		opts:     newOptions(opts),
+		mu:       &sync.Mutex{},
+	}
+}
+
+// Implements [Cooldown]
+func (cd *TokenBucketCooldown) Hit(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	// repeating cancelation check, since lock may have taken a long time
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := cd.opts.clock.Now()
+	tat := cd.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	// bucket is empty, if the burst does not cover the hits ahead of this one
+	wait := tat.Sub(now) - time.Duration(cd.burst-1)*cd.interval
+	if err := sleep(ctx, cd.opts.clock, cd.opts.jitteredUp(wait)); err != nil {
+		return err
+	}
+
+	cd.tat = tat.Add(cd.interval)
+	return nil
+}
@@ -0,0 +1,102 @@
+package cooldown
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketCooldown_Hit_Burst(t *testing.T) {
+	interval := 100 * time.Millisecond
+	clock := NewFakeClock(time.Unix(0, 0))
+	cd := NewTokenBucketCooldown(interval, 3, WithClock(clock))
+
+	// full bucket
+	for i := range 3 {
+		if got := hitDelay(t, clock, cd); got != 0 {
+			t.Errorf("hit %d: expected no delay within burst, got %v", i, got)
+		}
+	}
+
+	// empty bucket
+	for i := range 3 {
+		if got := hitDelay(t, clock, cd); got != interval {
+			t.Errorf("hit %d: expected delay %v, got %v", i, interval, got)
+		}
+	}
+
+	// refilled with two tokens
+	clock.Advance(2*interval + interval/2)
+	for i := range 2 {
+		if got := hitDelay(t, clock, cd); got != 0 {
+			t.Errorf("hit %d: expected no delay after refill, got %v", i, got)
+		}
+	}
+	if got := hitDelay(t, clock, cd); got != interval/2 {
+		t.Errorf("expected the rest of the partial token, %v, got %v", interval/2, got)
+	}
+}
+
+func TestTokenBucketCooldown_Hit_ContextCanceled(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	cd := NewTokenBucketCooldown(time.Second, 1, WithClock(clock))
+
+	if err := cd.Hit(context.Background()); err != nil {
+		t.Fatalf("expected nil err, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cd.Hit(ctx)
+	}()
+
+	clock.BlockUntil(1)
+	cancel()
+
+	// strict check
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("expected '%v', got '%v'", context.Canceled, err)
+	}
+
+	// canceled hit has not taken a token
+	if got := hitDelay(t, clock, cd); got != time.Second {
+		t.Errorf("expected delay %v, got %v", time.Second, got)
+	}
+}
+
+func TestTokenBucketCooldown_Hit_Jitter(t *testing.T) {
+	interval := 100 * time.Millisecond
+
+	for _, tc := range []struct {
+		name     string
+		jitter   Jitter
+		min, max time.Duration
+	}{
+		{"none", NoJitter, interval, interval},
+		{"full", FullJitter, interval, 2 * interval},
+		{"equal", EqualJitter, interval, interval + interval/2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 2))
+
+			distinct := map[time.Duration]bool{}
+			for range 20 {
+				clock := NewFakeClock(time.Unix(0, 0))
+				cd := NewTokenBucketCooldown(interval, 1, WithClock(clock), WithJitter(tc.jitter), WithRand(r))
+
+				hitDelay(t, clock, cd)
+				// the bucket is spent: the limit is never jittered away
+				got := hitDelay(t, clock, cd)
+				if got < tc.min || got > tc.max {
+					t.Errorf("expected delay in [%v, %v], got %v", tc.min, tc.max, got)
+				}
+				distinct[got] = true
+			}
+			if tc.jitter != NoJitter && len(distinct) < 2 {
+				t.Errorf("expected delays to be spread, got %v", distinct)
+			}
+		})
+	}
+}